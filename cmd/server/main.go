@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kzmnbrs/actkn"
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http"
+	"github.com/boris-army/server/internal/adapters/http/middleware"
+	"github.com/boris-army/server/internal/adapters/http/render"
+	"github.com/boris-army/server/internal/config"
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/core/ports"
+	"github.com/boris-army/server/internal/impl/attestation"
+	"github.com/boris-army/server/internal/impl/hashpool"
+	"github.com/boris-army/server/internal/impl/session"
+	"github.com/boris-army/server/internal/impl/textnq"
+	"github.com/boris-army/server/internal/impl/user"
+)
+
+func main() {
+	confPath := flag.String("config", "", "path to the JSON config file")
+	flag.Parse()
+
+	conf, err := config.Load(*confPath)
+	if err != nil {
+		log.Fatalln("server: can't load config:", err)
+	}
+
+	render.SetAuthRealm(conf.Http.AuthRealm)
+
+	tokenSources, err := middleware.ParseTokenSources(conf.Http.TokenSources)
+	if err != nil {
+		log.Fatalln("server: can't parse token sources:", err)
+	}
+	dpop := middleware.NewDpop(conf.Http.DpopBaseUrl, time.Duration(conf.Http.DpopSkew))
+	csrf := middleware.NewCsrf(conf.Token.Secret, conf.Http.CsrfOrigins)
+
+	var cookie *http.SessionCookie
+	if conf.Http.Cookies {
+		sameSite, err := http.ParseCookieSameSite(conf.Http.CookieSameSite)
+		if err != nil {
+			log.Fatalln("server: can't parse cookie same site:", err)
+		}
+		cookie = &http.SessionCookie{
+			Domain:   conf.Http.CookieDomain,
+			Secure:   conf.Http.CookieSecure,
+			SameSite: sameSite,
+			Csrf:     csrf,
+		}
+	}
+
+	var policy *middleware.Policy
+	if len(conf.Http.PolicyPath) > 0 {
+		if policy, err = middleware.LoadPolicy(conf.Http.PolicyPath); err != nil {
+			log.Fatalln("server: can't load policy:", err)
+		}
+	}
+
+	pool, err := connectPostgres(&conf.Postgres)
+	if err != nil {
+		log.Fatalln("server: can't connect to postgres:", err)
+	}
+	defer pool.Close()
+
+	sessions, err := session.NewPgxRepository(pool, &conf.Session)
+	if err != nil {
+		log.Fatalln("server: can't init session repository:", err)
+	}
+
+	sessionDriver := &session.Driver{
+		Sessions: sessions,
+		Actkn:    actkn.NewManager(conf.Token.Secret),
+	}
+
+	userDriver := &user.Driver{
+		Users:          &user.PgxRepository{Pool: pool},
+		PasswordHasher: newPasswordHasher(&conf.Password),
+		CodeDigester:   ports.Sha256SecretDigester{},
+		Throttles:      &user.PgxLoginThrottleRepository{Pool: pool},
+		Sessions:       sessionDriver,
+		Texts:          textnq.NewQueue(conf.Texts.QueueSize, conf.Texts.Workers),
+		DeliverEmail:   newEmailDeliverFn(&conf.Smtp),
+		DeliverSms:     newSmsDeliverFn(&conf.Sms),
+		Conf:           &conf.User,
+	}
+	go purgeUnconfirmedUsers(userDriver, time.Duration(conf.User.UnconfirmedPurgePeriod))
+	go purgeLoginThrottles(userDriver, time.Duration(conf.User.LoginFailureWindow))
+
+	reviewers := middleware.AnyOf(
+		middleware.RequireScope(domain.ScopeAttestationsReview),
+		middleware.AllowUserIds(conf.Attestation.ReviewerIds...),
+	)
+	loginKey := middleware.KeyJoin(middleware.KeyByRoute, middleware.KeyByIp)
+	adapter := &http.Adapter{
+		Users:        userDriver,
+		Sessions:     sessionDriver,
+		Attestations: &attestation.Driver{Attestations: &attestation.PgxRepository{Pool: pool}, Sessions: sessionDriver},
+		Access: &middleware.Access{
+			Sessions: sessionDriver,
+			Sources:  tokenSources,
+			Dpop:     dpop,
+			Csrf:     csrf,
+		},
+		Dpop:   dpop,
+		Cookie: cookie,
+		// The hashing pool gives up on a call after HashWait.
+		OverloadRetryAfter: time.Duration(conf.Password.HashWait),
+		Reviewers:          reviewers,
+		Policy:             policy,
+		UserLimit:          newRateLimit(conf.RateLimit.UserRate, conf.RateLimit.UserBurst, middleware.KeyByUserId),
+		LoginLimit:         newRateLimit(conf.RateLimit.LoginRate, conf.RateLimit.LoginBurst, loginKey),
+	}
+
+	router := &http.Router{}
+	adapter.Mount(router)
+
+	ipLimit := newRateLimit(conf.RateLimit.IpRate, conf.RateLimit.IpBurst, middleware.KeyByIp)
+	srv := &fasthttp.Server{
+		Handler: ipLimit.Apply(router.Handler),
+		Name:    conf.Http.AuthRealm,
+	}
+
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		<-sig
+		log.Println("server: shutting down")
+		if err := srv.Shutdown(); err != nil {
+			log.Println("server: can't shutdown gracefully:", err)
+		}
+	}()
+
+	log.Println("server: listening on", conf.Http.Addr)
+	if err := srv.ListenAndServe(conf.Http.Addr); err != nil {
+		log.Fatalln("server: can't serve:", err)
+	}
+}
+
+func connectPostgres(conf *config.Postgres) (*pgxpool.Pool, error) {
+	poolConf, err := pgxpool.ParseConfig(conf.Dsn)
+	if err != nil {
+		return nil, err
+	}
+	poolConf.MaxConns = conf.MaxConns
+	poolConf.MinConns = conf.MinConns
+	poolConf.MaxConnLifetime = time.Duration(conf.MaxConnLifetime)
+	return pgxpool.ConnectConfig(context.Background(), poolConf)
+}
+
+func newPasswordHasher(conf *config.Password) ports.PasswordHasher {
+	var h ports.PasswordHasher = &ports.BCryptPasswordHasher{Cost: conf.BCryptCost}
+	if conf.Algorithm == config.PasswordAlgorithmArgon2id {
+		h = &ports.Argon2idPasswordHasher{
+			Memory:      uint32(conf.Argon2Memory),
+			Iterations:  uint32(conf.Argon2Iterations),
+			Parallelism: uint8(conf.Argon2Parallelism),
+			SaltLen:     16,
+			KeyLen:      32,
+		}
+	}
+	return hashpool.NewPool(h, conf.HashWorkers, conf.HashQueueSize, time.Duration(conf.HashWait))
+}
+
+func newEmailDeliverFn(conf *config.Smtp) ports.DriverTextNSDeliverFn {
+	if len(conf.Addr) == 0 {
+		log.Println("server: smtp.addr is not set, emails will be logged")
+		return textnq.LogDeliver
+	}
+
+	s := &textnq.Smtp{Addr: conf.Addr, From: conf.From}
+	if len(conf.Username) > 0 {
+		host, _, _ := net.SplitHostPort(conf.Addr)
+		s.Auth = smtp.PlainAuth("", conf.Username, conf.Password, host)
+	}
+	return s.Deliver
+}
+
+func newSmsDeliverFn(conf *config.Sms) ports.DriverTextNSDeliverFn {
+	if len(conf.GatewayUrl) == 0 {
+		log.Println("server: sms.gateway_url is not set, text messages will be logged")
+		return textnq.LogDeliver
+	}
+
+	g := &textnq.SmsGateway{
+		Url:     conf.GatewayUrl,
+		Token:   conf.GatewayToken,
+		Timeout: time.Duration(conf.Timeout),
+	}
+	return g.Deliver
+}
+
+// newRateLimit returns nil for a zero rate, which disables the limit.
+func newRateLimit(rate float64, burst int, keyFn middleware.RateLimitKeyFn) *middleware.RateLimit {
+	if rate == 0 {
+		return nil
+	}
+	return middleware.NewRateLimit(rate, burst, keyFn)
+}
+
+func purgeUnconfirmedUsers(d ports.DriverUser, period time.Duration) {
+	for {
+		<-time.After(period)
+		n, err := d.PurgeUnconfirmed()
+		if err != nil {
+			log.Println("server: can't purge unconfirmed users:", err)
+			continue
+		}
+		if n > 0 {
+			log.Println("server: purged", n, "unconfirmed users")
+		}
+	}
+}
+
+func purgeLoginThrottles(d ports.DriverUser, period time.Duration) {
+	for {
+		<-time.After(period)
+		n, err := d.PurgeLoginThrottles()
+		if err != nil {
+			log.Println("server: can't purge login throttles:", err)
+			continue
+		}
+		if n > 0 {
+			log.Println("server: purged", n, "login throttles")
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package http
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/render"
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/core/ports"
+)
+
+//go:generate easyjson $GOFILE
+
+//easyjson:json
+type AttestationPostCtx struct {
+	Note   string                         `json:"note,nocopy"`
+	Submit ports.CommandAttestationSubmit `json:"-"`
+}
+
+func (r *AttestationPostCtx) Reset() {
+	r.Note = ""
+	r.Submit.Reset()
+}
+
+var attestationPostCtxPool = sync.Pool{
+	New: func() any {
+		return &AttestationPostCtx{}
+	},
+}
+
+// AttestationPost submits the caller's attestation request for review.
+func (a *Adapter) AttestationPost(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	ctx := attestationPostCtxPool.Get().(*AttestationPostCtx)
+	defer func() {
+		ctx.Reset()
+		attestationPostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	cmd := &ctx.Submit
+	cmd.UserId = tok.User.Id
+	cmd.Note = ctx.Note
+	if err := a.Attestations.Submit(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		case domain.ErrExists:
+			render.ErrConflict(req, render.CodeAttestationExists, "")
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":{"id":`)
+	_, _ = req.WriteString(strconv.FormatInt(cmd.Result.Id, 10))
+	_, _ = req.WriteString(`}}`)
+}
+
+//easyjson:json
+type AdminAttestationsGetRes struct {
+	Attestations []AdminAttestationsGetItem `json:"attestations"`
+	NextCursor   int64                      `json:"next_cursor,omitempty"`
+}
+
+type AdminAttestationsGetItem struct {
+	Id          int64  `json:"id"`
+	UserId      int64  `json:"user_id"`
+	Note        string `json:"note"`
+	SubmittedAt int64  `json:"submitted_at"`
+}
+
+type AdminAttestationsGetCtx struct {
+	ListPending ports.CommandAttestationListPending
+	Res         AdminAttestationsGetRes
+}
+
+func (r *AdminAttestationsGetCtx) Reset() {
+	r.ListPending.Reset()
+	r.Res.Attestations = r.Res.Attestations[:0]
+	r.Res.NextCursor = 0
+}
+
+var adminAttestationsGetCtxPool = sync.Pool{
+	New: func() any {
+		// Non-nil Attestations render an empty page as [] instead of null.
+		return &AdminAttestationsGetCtx{
+			Res: AdminAttestationsGetRes{
+				Attestations: make([]AdminAttestationsGetItem, 0, ports.AttestationListLimitDefault),
+			},
+		}
+	},
+}
+
+// AdminAttestationsGet lists the attestations awaiting review. Pages
+// are requested with the cursor and limit query args.
+func (a *Adapter) AdminAttestationsGet(req *fasthttp.RequestCtx, _ *domain.SessionHttpToken) {
+	ctx := adminAttestationsGetCtxPool.Get().(*AdminAttestationsGetCtx)
+	defer func() {
+		ctx.Reset()
+		adminAttestationsGetCtxPool.Put(ctx)
+	}()
+
+	cmd := &ctx.ListPending
+
+	args := req.QueryArgs()
+	if args.Has("cursor") {
+		cursor, err := args.GetUint("cursor")
+		if err != nil {
+			render.ErrBadReq(req, render.CodeValue, "cursor")
+			return
+		}
+		cmd.Cursor = int64(cursor)
+	}
+	if args.Has("limit") {
+		limit, err := args.GetUint("limit")
+		if err != nil {
+			render.ErrBadReq(req, render.CodeValue, "limit")
+			return
+		}
+		cmd.Limit = limit
+	}
+
+	if err := a.Attestations.ListPending(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	res := &ctx.Res
+	for i := range cmd.Result.Attestations {
+		at := &cmd.Result.Attestations[i]
+		res.Attestations = append(res.Attestations, AdminAttestationsGetItem{
+			Id:          at.Id,
+			UserId:      at.UserId,
+			Note:        at.Note,
+			SubmittedAt: at.SubmittedAt.Unix(),
+		})
+	}
+	res.NextCursor = cmd.Result.NextCursor
+
+	resData, err := res.MarshalJSON()
+	if err != nil {
+		render.ErrInternal(req, "")
+		return
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":`)
+	_, _ = req.Write(resData)
+	_, _ = req.WriteString(`}`)
+}
+
+//easyjson:json
+type AdminAttestationDecisionPostCtx struct {
+	Id       int64                          `json:"id"`
+	Decision string                         `json:"decision,nocopy"`
+	Reason   string                         `json:"reason,nocopy"`
+	Decide   ports.CommandAttestationDecide `json:"-"`
+}
+
+func (r *AdminAttestationDecisionPostCtx) Reset() {
+	r.Id = 0
+	r.Decision = ""
+	r.Reason = ""
+	r.Decide.Reset()
+}
+
+var adminAttestationDecisionPostCtxPool = sync.Pool{
+	New: func() any {
+		return &AdminAttestationDecisionPostCtx{}
+	},
+}
+
+var attestationDecisions = map[string]domain.AttestationStatus{
+	"approve": domain.AttestationStatusApproved,
+	"reject":  domain.AttestationStatusRejected,
+	"revoke":  domain.AttestationStatusRevoked,
+}
+
+// AdminAttestationDecisionPost records the caller's decision on an
+// attestation: approve, reject or revoke.
+func (a *Adapter) AdminAttestationDecisionPost(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	ctx := adminAttestationDecisionPostCtxPool.Get().(*AdminAttestationDecisionPostCtx)
+	defer func() {
+		ctx.Reset()
+		adminAttestationDecisionPostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	cmd := &ctx.Decide
+	cmd.Id = ctx.Id
+	cmd.ReviewerId = tok.User.Id
+	cmd.Decision = attestationDecisions[ctx.Decision]
+	cmd.Reason = ctx.Reason
+	if err := a.Attestations.Decide(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		case domain.ErrKey:
+			render.ErrNotFound(req)
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":"decided"}`)
+}
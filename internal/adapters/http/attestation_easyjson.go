@@ -0,0 +1,333 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package http
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson6c69ed44DecodeGithubComBorisArmyServerInternalAdaptersHttp(in *jlexer.Lexer, out *AttestationPostCtx) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "note":
+			out.Note = string(in.UnsafeString())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6c69ed44EncodeGithubComBorisArmyServerInternalAdaptersHttp(out *jwriter.Writer, in AttestationPostCtx) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"note\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Note))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v AttestationPostCtx) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6c69ed44EncodeGithubComBorisArmyServerInternalAdaptersHttp(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v AttestationPostCtx) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6c69ed44EncodeGithubComBorisArmyServerInternalAdaptersHttp(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *AttestationPostCtx) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6c69ed44DecodeGithubComBorisArmyServerInternalAdaptersHttp(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *AttestationPostCtx) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6c69ed44DecodeGithubComBorisArmyServerInternalAdaptersHttp(l, v)
+}
+func easyjson6c69ed44DecodeGithubComBorisArmyServerInternalAdaptersHttp1(in *jlexer.Lexer, out *AdminAttestationsGetRes) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "attestations":
+			if in.IsNull() {
+				in.Skip()
+				out.Attestations = nil
+			} else {
+				in.Delim('[')
+				if out.Attestations == nil {
+					if !in.IsDelim(']') {
+						out.Attestations = make([]AdminAttestationsGetItem, 0, 1)
+					} else {
+						out.Attestations = []AdminAttestationsGetItem{}
+					}
+				} else {
+					out.Attestations = (out.Attestations)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 AdminAttestationsGetItem
+					easyjson6c69ed44DecodeGithubComBorisArmyServerInternalAdaptersHttp2(in, &v1)
+					out.Attestations = append(out.Attestations, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "next_cursor":
+			out.NextCursor = int64(in.Int64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6c69ed44EncodeGithubComBorisArmyServerInternalAdaptersHttp1(out *jwriter.Writer, in AdminAttestationsGetRes) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"attestations\":"
+		out.RawString(prefix[1:])
+		if in.Attestations == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Attestations {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				easyjson6c69ed44EncodeGithubComBorisArmyServerInternalAdaptersHttp2(out, v3)
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.NextCursor != 0 {
+		const prefix string = ",\"next_cursor\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.NextCursor))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v AdminAttestationsGetRes) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6c69ed44EncodeGithubComBorisArmyServerInternalAdaptersHttp1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v AdminAttestationsGetRes) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6c69ed44EncodeGithubComBorisArmyServerInternalAdaptersHttp1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *AdminAttestationsGetRes) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6c69ed44DecodeGithubComBorisArmyServerInternalAdaptersHttp1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *AdminAttestationsGetRes) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6c69ed44DecodeGithubComBorisArmyServerInternalAdaptersHttp1(l, v)
+}
+func easyjson6c69ed44DecodeGithubComBorisArmyServerInternalAdaptersHttp2(in *jlexer.Lexer, out *AdminAttestationsGetItem) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = int64(in.Int64())
+		case "user_id":
+			out.UserId = int64(in.Int64())
+		case "note":
+			out.Note = string(in.String())
+		case "submitted_at":
+			out.SubmittedAt = int64(in.Int64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6c69ed44EncodeGithubComBorisArmyServerInternalAdaptersHttp2(out *jwriter.Writer, in AdminAttestationsGetItem) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.Int64(int64(in.Id))
+	}
+	{
+		const prefix string = ",\"user_id\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.UserId))
+	}
+	{
+		const prefix string = ",\"note\":"
+		out.RawString(prefix)
+		out.String(string(in.Note))
+	}
+	{
+		const prefix string = ",\"submitted_at\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.SubmittedAt))
+	}
+	out.RawByte('}')
+}
+func easyjson6c69ed44DecodeGithubComBorisArmyServerInternalAdaptersHttp3(in *jlexer.Lexer, out *AdminAttestationDecisionPostCtx) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = int64(in.Int64())
+		case "decision":
+			out.Decision = string(in.UnsafeString())
+		case "reason":
+			out.Reason = string(in.UnsafeString())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6c69ed44EncodeGithubComBorisArmyServerInternalAdaptersHttp3(out *jwriter.Writer, in AdminAttestationDecisionPostCtx) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.Int64(int64(in.Id))
+	}
+	{
+		const prefix string = ",\"decision\":"
+		out.RawString(prefix)
+		out.String(string(in.Decision))
+	}
+	{
+		const prefix string = ",\"reason\":"
+		out.RawString(prefix)
+		out.String(string(in.Reason))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v AdminAttestationDecisionPostCtx) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6c69ed44EncodeGithubComBorisArmyServerInternalAdaptersHttp3(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v AdminAttestationDecisionPostCtx) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6c69ed44EncodeGithubComBorisArmyServerInternalAdaptersHttp3(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *AdminAttestationDecisionPostCtx) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6c69ed44DecodeGithubComBorisArmyServerInternalAdaptersHttp3(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *AdminAttestationDecisionPostCtx) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6c69ed44DecodeGithubComBorisArmyServerInternalAdaptersHttp3(l, v)
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"io"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/core/ports"
+	"github.com/boris-army/server/internal/impl/attestation"
+)
+
+func TestAdminAttestationDecisionPost_Response(t *testing.T) {
+	type tc struct {
+		name         string
+		reqBody      string
+		expDecision  domain.AttestationStatus
+		driverErr    error
+		expRes       string
+		expResStatus int
+	}
+	tcs := []tc{
+		{"unknown decision", `{"id":1,"decision":"maybe"}`, 0, domain.ErrValue, `{"err":{"code":"VALUE"}}`, fasthttp.StatusBadRequest},
+		{"not found", `{"id":1,"decision":"approve"}`, domain.AttestationStatusApproved, domain.ErrKey, `{"err":{"code":"NOT_FOUND"}}`, fasthttp.StatusNotFound},
+		{"internal error", `{"id":1,"decision":"approve"}`, domain.AttestationStatusApproved, io.ErrShortWrite, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{"ok", `{"id":1,"decision":"revoke","reason":"forged"}`, domain.AttestationStatusRevoked, nil, `{"res":"decided"}`, fasthttp.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			attDriver := attestation.NewMockDriverAttestation(ctrl)
+			a := Adapter{Attestations: attDriver}
+
+			attDriver.EXPECT().Decide(gomock.Any()).
+				DoAndReturn(func(cmd *ports.CommandAttestationDecide) error {
+					assert.Equal(t, int64(1), cmd.Id)
+					assert.Equal(t, int64(2), cmd.ReviewerId)
+					assert.Equal(t, tc.expDecision, cmd.Decision)
+					return tc.driverErr
+				})
+
+			req := &fasthttp.RequestCtx{}
+			req.Request.SetBody([]byte(tc.reqBody))
+			a.AdminAttestationDecisionPost(req, &domain.SessionHttpToken{User: domain.SessionHttpTokenUser{Id: 2}})
+
+			assert.Equal(t, tc.expResStatus, req.Response.StatusCode())
+			assert.Equal(t, tc.expRes, string(req.Response.Body()))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/middleware"
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+const (
+	cookieAccessToken = "access_token"
+	// cookieCsrfToken is readable by the scripts of the site, they
+	// send it back in the middleware.CsrfHeader.
+	cookieCsrfToken = "csrf_token"
+)
+
+// SessionCookie issues the access_token cookie to the browser clients
+// asking the login endpoints for it. The token is HttpOnly, so the
+// unsafe requests it authenticates are checked by middleware.Csrf.
+type SessionCookie struct {
+	Domain   string
+	Secure   bool
+	SameSite fasthttp.CookieSameSite
+	// Csrf must be the one of middleware.Access.
+	Csrf *middleware.Csrf
+}
+
+var cookieSameSiteNames = map[string]fasthttp.CookieSameSite{
+	"strict": fasthttp.CookieSameSiteStrictMode,
+	"lax":    fasthttp.CookieSameSiteLaxMode,
+	"none":   fasthttp.CookieSameSiteNoneMode,
+}
+
+// ParseCookieSameSite parses the "strict", "lax" and "none" names.
+func ParseCookieSameSite(name string) (fasthttp.CookieSameSite, error) {
+	mode, ok := cookieSameSiteNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown cookie same site %q", name)
+	}
+	return mode, nil
+}
+
+// write sets the cookies and writes the token response without the
+// token itself. The CSRF token is base64url, so it needs no JSON
+// escaping.
+func (c *SessionCookie) write(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken, tokRaw []byte) {
+	expiresAt := time.Unix(tok.ExpiresAt, 0)
+	csrfTok := c.Csrf.Token(tok.SessionId)
+
+	c.set(req, cookieAccessToken, tokRaw, expiresAt, true)
+	c.set(req, cookieCsrfToken, []byte(csrfTok), expiresAt, false)
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":{"expires_at":`)
+	_, _ = req.WriteString(strconv.FormatInt(tok.ExpiresAt, 10))
+	_, _ = req.WriteString(`,"csrf_token":"`)
+	_, _ = req.WriteString(csrfTok)
+	_, _ = req.WriteString(`"}}`)
+}
+
+// clear makes the client drop the cookies, if they are issued at all.
+func (c *SessionCookie) clear(req *fasthttp.RequestCtx) {
+	if c == nil {
+		return
+	}
+	c.set(req, cookieAccessToken, nil, fasthttp.CookieExpireDelete, true)
+	c.set(req, cookieCsrfToken, nil, fasthttp.CookieExpireDelete, false)
+}
+
+func (c *SessionCookie) set(req *fasthttp.RequestCtx, key string, value []byte, expiresAt time.Time, httpOnly bool) {
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(key)
+	cookie.SetValueBytes(value)
+	cookie.SetDomain(c.Domain)
+	cookie.SetPath("/")
+	cookie.SetExpire(expiresAt)
+	cookie.SetHTTPOnly(httpOnly)
+	cookie.SetSecure(c.Secure)
+	cookie.SetSameSite(c.SameSite)
+	req.Response.Header.SetCookie(cookie)
+}
@@ -1,7 +1,33 @@
 package http
 
-import "github.com/boris-army/server/internal/core/ports"
+import (
+	"time"
+
+	"github.com/boris-army/server/internal/adapters/http/middleware"
+	"github.com/boris-army/server/internal/core/ports"
+)
 
 type Adapter struct {
-	Users ports.DriverUser
+	Users        ports.DriverUser
+	Sessions     ports.DriverSession
+	Attestations ports.DriverAttestation
+	Access       *middleware.Access
+	// Dpop binds the issued tokens to the client keys of the DPoP
+	// proofs sent along, it is optional.
+	Dpop *middleware.Dpop
+	// Cookie issues the tokens as cookies to the clients asking
+	// for it, it is optional.
+	Cookie *SessionCookie
+	// Reviewers guards the attestation review endpoints.
+	Reviewers middleware.AccessEnforcerFn
+	// Policy tightens the enforcers of the routes it matches,
+	// it is optional.
+	Policy *middleware.Policy
+	// UserLimit bounds the authenticated requests, LoginLimit the
+	// credential ones. Either is optional.
+	UserLimit  *middleware.RateLimit
+	LoginLimit *middleware.RateLimit
+	// OverloadRetryAfter is advertised along with 503 responses
+	// to domain.ErrOverloaded.
+	OverloadRetryAfter time.Duration
 }
@@ -1,6 +1,10 @@
 package middleware
 
 import (
+	"bytes"
+	"fmt"
+
+	"github.com/kzmnbrs/sly"
 	"github.com/valyala/fasthttp"
 
 	"github.com/boris-army/server/internal/adapters/http/render"
@@ -10,16 +14,102 @@ import (
 
 type Access struct {
 	Sessions ports.DriverSession
+	// Sources are looked up for the token in the order of the
+	// TokenSource constants, all of them if zero.
+	Sources TokenSource
+	// Dpop verifies the proofs of the tokens bound to a client key,
+	// such tokens are rejected if it is nil.
+	Dpop *Dpop
+	// Csrf checks the unsafe requests authenticated by the cookie,
+	// such requests are rejected if it is nil.
+	Csrf *Csrf
+}
+
+// TokenSource is a set of the request parts the token is taken from.
+type TokenSource = int
+
+const (
+	// TokenSourceHeader is the Authorization header with either
+	// the Bearer or the DPoP scheme.
+	TokenSourceHeader TokenSource = 1 << iota
+	// TokenSourceQuery is the access_token query arg. URLs get logged,
+	// so are the tokens in them.
+	TokenSourceQuery
+	// TokenSourceCookie is the access_token cookie.
+	TokenSourceCookie
+
+	TokenSourceAll = TokenSourceHeader | TokenSourceQuery | TokenSourceCookie
+)
+
+var tokenSourceNames = map[string]TokenSource{
+	"header": TokenSourceHeader,
+	"query":  TokenSourceQuery,
+	"cookie": TokenSourceCookie,
+}
+
+// ParseTokenSources parses the "header", "query" and "cookie" names.
+func ParseTokenSources(names []string) (TokenSource, error) {
+	var sources TokenSource
+	for _, name := range names {
+		source, ok := tokenSourceNames[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown token source %q", name)
+		}
+		sources |= source
+	}
+	return sources, nil
+}
+
+// WithSources returns a copy of the middleware taking the token from
+// the given sources, e.g. for a route that must not accept cookies.
+func (m *Access) WithSources(sources TokenSource) *Access {
+	c := *m
+	c.Sources = sources
+	return &c
 }
 
+// HandlerWithAccess gets the decoded token of the request. The token
+// is nil for anonymous requests passed by ApplyOptional.
 type HandlerWithAccess = func(*fasthttp.RequestCtx, *domain.SessionHttpToken)
 
 type AccessEnforcerFn = func(*domain.SessionHttpToken) bool
 
+// AllowAny grants access to any valid token.
+func AllowAny(*domain.SessionHttpToken) bool {
+	return true
+}
+
+// AllowUserIds grants access to the given users only.
+func AllowUserIds(ids ...int64) AccessEnforcerFn {
+	allowed := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		allowed[id] = struct{}{}
+	}
+	return func(tok *domain.SessionHttpToken) bool {
+		_, ok := allowed[tok.User.Id]
+		return ok
+	}
+}
+
 func (m *Access) Apply(next HandlerWithAccess, enforcerFn AccessEnforcerFn) fasthttp.RequestHandler {
+	return m.apply(next, enforcerFn, false)
+}
+
+// ApplyOptional is Apply for endpoints open to anonymous clients: a
+// request without a token reaches next with a nil token. A token sent
+// along is checked as by Apply.
+func (m *Access) ApplyOptional(next HandlerWithAccess, enforcerFn AccessEnforcerFn) fasthttp.RequestHandler {
+	return m.apply(next, enforcerFn, true)
+}
+
+func (m *Access) apply(next HandlerWithAccess, enforcerFn AccessEnforcerFn, optional bool) fasthttp.RequestHandler {
 	return func(req *fasthttp.RequestCtx) {
-		tokRaw := m.getTokenRaw(req)
+		tokRaw, source, isDpop := m.getTokenRaw(req)
 		if len(tokRaw) == 0 {
+			if optional {
+				next(req, nil)
+				return
+			}
 			render.ErrAccessTokenRequired(req)
 			return
 		}
@@ -27,7 +117,13 @@ func (m *Access) Apply(next HandlerWithAccess, enforcerFn AccessEnforcerFn) fast
 		tok := domain.AcquireSessionHttpToken()
 		defer domain.ReleaseSessionHttpToken(tok)
 
-		if err := m.Sessions.DecodeHttpTokenTo(tok, tokRaw); err != nil {
+		// The token is decoded in place, while the DPoP proof covers
+		// it as sent.
+		decodeRaw := tokRaw
+		if isDpop {
+			decodeRaw = append([]byte(nil), tokRaw...)
+		}
+		if err := m.Sessions.DecodeHttpTokenTo(tok, decodeRaw); err != nil {
 			switch err {
 			case domain.ErrValue:
 				render.ErrAccessTokenInvalid(req)
@@ -47,6 +143,15 @@ func (m *Access) Apply(next HandlerWithAccess, enforcerFn AccessEnforcerFn) fast
 			}
 		}
 
+		if (isDpop || len(tok.Jkt) > 0) && !m.checkDpop(req, tokRaw, tok, isDpop) {
+			return
+		}
+
+		if source == TokenSourceCookie && !isSafeMethod(req) && !m.Csrf.Check(req, tok) {
+			render.ErrCsrfInvalid(req)
+			return
+		}
+
 		if !enforcerFn(tok) {
 			render.ErrAccessTokenInsufficient(req)
 			return
@@ -56,17 +161,58 @@ func (m *Access) Apply(next HandlerWithAccess, enforcerFn AccessEnforcerFn) fast
 	}
 }
 
-func (m *Access) getTokenRaw(req *fasthttp.RequestCtx) []byte {
-	authHdr := req.Request.Header.Peek(fasthttp.HeaderAuthorization)
-	const nBearerPrefix = len("Bearer ")
-	if len(authHdr) > nBearerPrefix {
-		return authHdr[nBearerPrefix:]
+// getTokenRaw takes the token from the first source having it.
+// source is the one it is taken from, isDpop tells whether the token
+// is sent with the DPoP scheme.
+func (m *Access) getTokenRaw(req *fasthttp.RequestCtx) (tokRaw []byte, source TokenSource, isDpop bool) {
+	sources := m.Sources
+	if sources == 0 {
+		sources = TokenSourceAll
 	}
 
-	tokValue := req.QueryArgs().Peek("access_token")
-	if len(tokValue) > 0 {
-		return tokValue
+	if sources&TokenSourceHeader != 0 {
+		authHdr := req.Request.Header.Peek(fasthttp.HeaderAuthorization)
+		if tokRaw := cutAuthScheme(authHdr, "Bearer "); len(tokRaw) > 0 {
+			return tokRaw, TokenSourceHeader, false
+		}
+		if tokRaw := cutAuthScheme(authHdr, "DPoP "); len(tokRaw) > 0 {
+			return tokRaw, TokenSourceHeader, true
+		}
 	}
 
-	return req.Request.Header.Cookie("access_token")
+	if sources&TokenSourceQuery != 0 {
+		if tokValue := req.QueryArgs().Peek("access_token"); len(tokValue) > 0 {
+			return tokValue, TokenSourceQuery, false
+		}
+	}
+
+	if sources&TokenSourceCookie != 0 {
+		if tokValue := req.Request.Header.Cookie("access_token"); len(tokValue) > 0 {
+			return tokValue, TokenSourceCookie, false
+		}
+	}
+	return nil, 0, false
+}
+
+func cutAuthScheme(authHdr []byte, scheme string) []byte {
+	if len(authHdr) > len(scheme) && bytes.EqualFold(authHdr[:len(scheme)], sly.S2B(scheme)) {
+		return authHdr[len(scheme):]
+	}
+	return nil
+}
+
+// checkDpop renders the error unless the token is sent with the DPoP
+// scheme, bound to a client key and accompanied by its valid proof.
+func (m *Access) checkDpop(req *fasthttp.RequestCtx, tokRaw []byte, tok *domain.SessionHttpToken, isDpop bool) bool {
+	if !isDpop || len(tok.Jkt) == 0 {
+		render.ErrAccessTokenInvalid(req)
+		return false
+	}
+
+	jkt, err := m.Dpop.Verify(req, tokRaw)
+	if err != nil || jkt != tok.Jkt {
+		render.ErrDpopProofInvalid(req)
+		return false
+	}
+	return true
 }
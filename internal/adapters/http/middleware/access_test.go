@@ -84,7 +84,7 @@ func TestAccess_Apply(t *testing.T) {
 			sess := session.NewMockDriverSession(ctrl)
 			if tc.hasSomeTok {
 				sess.EXPECT().
-					DecodeHttpTokenTo(&domain.SessionHttpToken{}, []byte("tok")).
+					DecodeHttpTokenTo(gomock.AssignableToTypeOf(&domain.SessionHttpToken{}), []byte("tok")).
 					Return(tc.expDriverErr)
 			}
 
@@ -107,18 +107,67 @@ func TestAccess_Apply(t *testing.T) {
 	}
 }
 
+func TestAccess_ApplyOptional(t *testing.T) {
+	type tc struct {
+		name         string
+		hasSomeTok   bool
+		expDriverErr error
+		expResCode   int
+		expResBody   []byte
+	}
+	tcs := []tc{
+		{"anonymous", false, nil, fasthttp.StatusOK, []byte(`anonymous`)},
+		{"ok", true, nil, fasthttp.StatusOK, []byte(`user`)},
+		{"invalid token", true, domain.ErrValue, fasthttp.StatusUnauthorized, []byte(`{"err":{"code":"` + render.CodeTokenInvalid + `"}}`)},
+		{"expired token", true, domain.ErrExpired, fasthttp.StatusUnauthorized, []byte(`{"err":{"code":"` + render.CodeTokenExpired + `"}}`)},
+		{"revoked token", true, domain.ErrSessionTerminated, fasthttp.StatusUnauthorized, []byte(`{"err":{"code":"` + render.CodeTokenRevoked + `"}}`)},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sess := session.NewMockDriverSession(ctrl)
+			if tc.hasSomeTok {
+				sess.EXPECT().
+					DecodeHttpTokenTo(gomock.AssignableToTypeOf(&domain.SessionHttpToken{}), []byte("tok")).
+					Return(tc.expDriverErr)
+			}
+
+			mw := Access{Sessions: sess}
+			handler := mw.ApplyOptional(func(req *fasthttp.RequestCtx, token *domain.SessionHttpToken) {
+				if token == nil {
+					_, _ = req.WriteString("anonymous")
+					return
+				}
+				_, _ = req.WriteString("user")
+			}, AllowAny)
+
+			req := fasthttp.RequestCtx{}
+			if tc.hasSomeTok {
+				req.Request.Header.Set(fasthttp.HeaderAuthorization, "Bearer tok")
+			}
+
+			handler(&req)
+			assert.Equal(t, tc.expResCode, req.Response.StatusCode())
+			assert.Equal(t, tc.expResBody, req.Response.Body())
+		})
+	}
+}
+
 func TestAccess_getTokenRaw(t *testing.T) {
 	type tc struct {
 		name string
-		req  fasthttp.Request
+		req  *fasthttp.Request
 	}
-	headerReq := fasthttp.Request{}
+	headerReq := &fasthttp.Request{}
 	headerReq.Header.Set(fasthttp.HeaderAuthorization, "Bearer tok")
 
-	queryReq := fasthttp.Request{}
+	queryReq := &fasthttp.Request{}
 	queryReq.SetRequestURI("https://example.com/private?access_token=tok")
 
-	cookieReq := fasthttp.Request{}
+	cookieReq := &fasthttp.Request{}
 	cookieReq.Header.SetCookie("access_token", "tok")
 
 	tcs := []tc{
@@ -138,9 +187,17 @@ func TestAccess_getTokenRaw(t *testing.T) {
 
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
-			ctx := fasthttp.RequestCtx{Request: tc.req}
-			tok := (&Access{}).getTokenRaw(&ctx)
+			ctx := &fasthttp.RequestCtx{}
+			tc.req.CopyTo(&ctx.Request)
+			tok, _, _ := (&Access{}).getTokenRaw(ctx)
 			assert.Equal(t, tok, []byte("tok"))
 		})
 	}
 }
+
+func TestAllowUserIds(t *testing.T) {
+	enforcerFn := AllowUserIds(1, 2)
+	assert.True(t, enforcerFn(&domain.SessionHttpToken{User: domain.SessionHttpTokenUser{Id: 2}}))
+	assert.False(t, enforcerFn(&domain.SessionHttpToken{User: domain.SessionHttpTokenUser{Id: 3}}))
+	assert.False(t, AllowUserIds()(&domain.SessionHttpToken{User: domain.SessionHttpTokenUser{Id: 1}}))
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+// CsrfHeader carries the CSRF token of the state-changing requests
+// authenticated by the access_token cookie.
+const CsrfHeader = "X-CSRF-Token"
+
+// Csrf protects the cookie sessions from cross-site request forgery.
+// The token is a MAC of the session id, so it needs no storage and
+// a token injected by a sibling domain doesn't fit a foreign session.
+//
+// A nil *Csrf rejects every request it checks.
+type Csrf struct {
+	key []byte
+	// Origins, if set, are the only Origin header values accepted.
+	// Requests without the header are judged by the token only.
+	Origins []string
+}
+
+func NewCsrf(secret string, origins []string) *Csrf {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("csrf"))
+	return &Csrf{key: mac.Sum(nil), Origins: origins}
+}
+
+// Token returns the CSRF token of the session.
+func (c *Csrf) Token(sessionId int64) string {
+	var buf [sha256.Size]byte
+	return base64.RawURLEncoding.EncodeToString(c.appendMac(buf[:0], sessionId))
+}
+
+func (c *Csrf) appendMac(dst []byte, sessionId int64) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(strconv.AppendInt(nil, sessionId, 10))
+	return mac.Sum(dst)
+}
+
+// Check tells whether the request carries the CSRF token of the
+// session and comes from an allowed origin.
+func (c *Csrf) Check(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) bool {
+	if c == nil {
+		return false
+	}
+
+	if origin := req.Request.Header.Peek("Origin"); len(origin) > 0 && len(c.Origins) > 0 {
+		allowed := false
+		for _, o := range c.Origins {
+			if o == string(origin) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	var sent [sha256.Size]byte
+	hdr := req.Request.Header.Peek(CsrfHeader)
+	if base64.RawURLEncoding.DecodedLen(len(hdr)) != len(sent) {
+		return false
+	}
+	if _, err := base64.RawURLEncoding.Decode(sent[:], hdr); err != nil {
+		return false
+	}
+
+	var buf [sha256.Size]byte
+	return subtle.ConstantTimeCompare(sent[:], c.appendMac(buf[:0], tok.SessionId)) == 1
+}
+
+// isSafeMethod tells whether the request method is not supposed
+// to change any state, so it needs no CSRF protection.
+func isSafeMethod(req *fasthttp.RequestCtx) bool {
+	return req.IsGet() || req.IsHead() || req.IsOptions() || req.IsTrace()
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/render"
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/impl/session"
+)
+
+func TestCsrf_Check(t *testing.T) {
+	c := NewCsrf("0123456789abcdef0123456789abcdef", []string{"https://boris.army"})
+	tok := &domain.SessionHttpToken{SessionId: 7}
+
+	assert.Len(t, c.Token(7), 43)
+	assert.NotEqual(t, c.Token(7), c.Token(8))
+	assert.NotEqual(t, c.Token(7), NewCsrf("fedcba9876543210fedcba9876543210", nil).Token(7))
+
+	type tc struct {
+		name    string
+		csrfTok string
+		origin  string
+		exp     bool
+	}
+	tcs := []tc{
+		{"ok", c.Token(7), "", true},
+		{"allowed origin", c.Token(7), "https://boris.army", true},
+		{"foreign origin", c.Token(7), "https://evil.me", false},
+		{"no token", "", "", false},
+		{"other session", c.Token(8), "", false},
+		{"malformed", "foo", "", false},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			req := fasthttp.RequestCtx{}
+			if len(tc.csrfTok) > 0 {
+				req.Request.Header.Set(CsrfHeader, tc.csrfTok)
+			}
+			if len(tc.origin) > 0 {
+				req.Request.Header.Set("Origin", tc.origin)
+			}
+			assert.Equal(t, tc.exp, c.Check(&req, tok))
+		})
+	}
+
+	var nilCsrf *Csrf
+	assert.False(t, nilCsrf.Check(&fasthttp.RequestCtx{}, tok))
+}
+
+func TestAccess_Apply_Csrf(t *testing.T) {
+	csrf := NewCsrf("0123456789abcdef0123456789abcdef", nil)
+
+	type tc struct {
+		name       string
+		method     string
+		authHdr    string
+		csrfTok    string
+		expResCode int
+		expResBody string
+	}
+	tcs := []tc{
+		{"cookie get", "GET", "", "", fasthttp.StatusOK, `ok`},
+		{"cookie post", "POST", "", csrf.Token(7), fasthttp.StatusOK, `ok`},
+		{"cookie post without token", "POST", "", "", fasthttp.StatusForbidden, `{"err":{"code":"` + render.CodeCsrfInvalid + `"}}`},
+		{"cookie delete with other token", "DELETE", "", csrf.Token(8), fasthttp.StatusForbidden, `{"err":{"code":"` + render.CodeCsrfInvalid + `"}}`},
+		{"header post", "POST", "Bearer tok", "", fasthttp.StatusOK, `ok`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sess := session.NewMockDriverSession(ctrl)
+			sess.EXPECT().DecodeHttpTokenTo(gomock.Any(), []byte("tok")).
+				DoAndReturn(func(dst *domain.SessionHttpToken, _ []byte) error {
+					dst.SessionId = 7
+					return nil
+				})
+
+			mw := Access{Sessions: sess, Csrf: csrf}
+			handler := mw.Apply(func(req *fasthttp.RequestCtx, _ *domain.SessionHttpToken) {
+				_, _ = req.WriteString("ok")
+			}, AllowAny)
+
+			req := fasthttp.RequestCtx{}
+			req.Request.Header.SetMethod(tc.method)
+			req.Request.SetRequestURI("/private")
+			req.Request.Header.SetCookie("access_token", "tok")
+			if len(tc.authHdr) > 0 {
+				req.Request.Header.Set(fasthttp.HeaderAuthorization, tc.authHdr)
+			}
+			if len(tc.csrfTok) > 0 {
+				req.Request.Header.Set(CsrfHeader, tc.csrfTok)
+			}
+
+			handler(&req)
+			assert.Equal(t, tc.expResCode, req.Response.StatusCode())
+			assert.Equal(t, tc.expResBody, string(req.Response.Body()))
+		})
+	}
+}
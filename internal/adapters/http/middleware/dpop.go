@@ -0,0 +1,283 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kzmnbrs/sly"
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+// Dpop verifies the DPoP proofs (RFC 9449) of the client keys the
+// tokens are bound to. ES256, EdDSA and RS256 proofs are accepted.
+//
+// Proof ids are remembered in the memory of the instance, so a proof
+// replayed to another instance is not detected.
+//
+// A nil *Dpop finds no proof in any request.
+type Dpop struct {
+	// BaseUrl is the scheme and host the clients send the requests to,
+	// the htu claim is checked against it. The ones of the request are
+	// used if empty, which is wrong behind a proxy.
+	BaseUrl string
+	// Skew bounds the difference of the iat claim and the server clock.
+	Skew time.Duration
+
+	mu       sync.Mutex
+	seen     map[string]time.Time
+	purgedAt time.Time
+	now      func() time.Time
+}
+
+func NewDpop(baseUrl string, skew time.Duration) *Dpop {
+	return &Dpop{
+		BaseUrl: strings.TrimSuffix(baseUrl, "/"),
+		Skew:    skew,
+		seen:    make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+var dpopHeaderName = []byte("DPoP")
+
+type dpopHeader struct {
+	Typ string  `json:"typ"`
+	Alg string  `json:"alg"`
+	Jwk dpopJwk `json:"jwk"`
+}
+
+type dpopJwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	D   string `json:"d"`
+}
+
+type dpopClaims struct {
+	Jti string `json:"jti"`
+	Htm string `json:"htm"`
+	Htu string `json:"htu"`
+	Iat int64  `json:"iat"`
+	Ath string `json:"ath"`
+}
+
+// Verify checks the DPoP proof of the request and returns the JWK
+// thumbprint of its key. The proof must cover the access token if
+// tokRaw is set, tokRaw is nil for the requests issuing tokens.
+// Errors:
+//
+//	domain.ErrKey - the request has no proof;
+//	domain.ErrValue - the proof is malformed, doesn't match the request
+//		or has been used before;
+//	domain.ErrExpired - the proof was issued too long ago.
+func (d *Dpop) Verify(req *fasthttp.RequestCtx, tokRaw []byte) (string, error) {
+	if d == nil {
+		return "", domain.ErrKey
+	}
+
+	var proof []byte
+	nProofs := 0
+	req.Request.Header.VisitAll(func(k, v []byte) {
+		if bytes.EqualFold(k, dpopHeaderName) {
+			proof = v
+			nProofs++
+		}
+	})
+	switch nProofs {
+	case 0:
+		return "", domain.ErrKey
+	case 1:
+	default:
+		return "", domain.ErrValue
+	}
+
+	parts := bytes.Split(proof, []byte{'.'})
+	if len(parts) != 3 {
+		return "", domain.ErrValue
+	}
+	var h dpopHeader
+	if err := decodeJwtPart(parts[0], &h); err != nil || h.Typ != "dpop+jwt" {
+		return "", domain.ErrValue
+	}
+	var c dpopClaims
+	if err := decodeJwtPart(parts[1], &c); err != nil {
+		return "", domain.ErrValue
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sly.B2S(parts[2]))
+	if err != nil {
+		return "", domain.ErrValue
+	}
+	signed := proof[:len(parts[0])+1+len(parts[1])]
+	if !h.Jwk.verify(h.Alg, signed, sig) {
+		return "", domain.ErrValue
+	}
+
+	if len(c.Jti) == 0 || len(c.Jti) > 256 {
+		return "", domain.ErrValue
+	}
+	if c.Htm != string(req.Method()) || stripUrlQuery(c.Htu) != d.requestUrl(req) {
+		return "", domain.ErrValue
+	}
+	if tokRaw != nil {
+		ath := sha256.Sum256(tokRaw)
+		if c.Ath != base64.RawURLEncoding.EncodeToString(ath[:]) {
+			return "", domain.ErrValue
+		}
+	}
+
+	now := d.now()
+	iat := time.Unix(c.Iat, 0)
+	if iat.Before(now.Add(-d.Skew)) {
+		return "", domain.ErrExpired
+	}
+	if iat.After(now.Add(d.Skew)) {
+		return "", domain.ErrValue
+	}
+	if !d.remember(c.Jti, now) {
+		return "", domain.ErrValue
+	}
+
+	return h.Jwk.thumbprint(), nil
+}
+
+func (d *Dpop) requestUrl(req *fasthttp.RequestCtx) string {
+	if len(d.BaseUrl) > 0 {
+		return d.BaseUrl + string(req.Path())
+	}
+	u := req.URI()
+	return string(u.Scheme()) + "://" + string(u.Host()) + string(u.Path())
+}
+
+// remember records the proof id, false if it is already known. Ids
+// are forgotten once the proofs carrying them are expired anyway.
+func (d *Dpop) remember(jti string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.purgedAt) >= d.Skew {
+		for id, expiresAt := range d.seen {
+			if now.After(expiresAt) {
+				delete(d.seen, id)
+			}
+		}
+		d.purgedAt = now
+	}
+
+	if _, ok := d.seen[jti]; ok {
+		return false
+	}
+	d.seen[jti] = now.Add(d.Skew * 2)
+	return true
+}
+
+func stripUrlQuery(u string) string {
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		return u[:i]
+	}
+	return u
+}
+
+func decodeJwtPart(part []byte, dst any) error {
+	data := make([]byte, base64.RawURLEncoding.DecodedLen(len(part)))
+	n, err := base64.RawURLEncoding.Decode(data, part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data[:n], dst)
+}
+
+// verify checks the signature made with the key. Private keys are
+// rejected, they must never leave the client.
+func (k *dpopJwk) verify(alg string, signed, sig []byte) bool {
+	if len(k.D) > 0 {
+		return false
+	}
+
+	switch alg {
+	case "ES256":
+		if k.Kty != "EC" || k.Crv != "P-256" || len(sig) != 64 {
+			return false
+		}
+		x, okX := decodeJwkInt(k.X, 32)
+		y, okY := decodeJwkInt(k.Y, 32)
+		if !okX || !okY {
+			return false
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pub.Curve.IsOnCurve(x, y) {
+			return false
+		}
+		digest := sha256.Sum256(signed)
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, digest[:], r, s)
+
+	case "EdDSA":
+		if k.Kty != "OKP" || k.Crv != "Ed25519" {
+			return false
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return false
+		}
+		return ed25519.Verify(x, signed, sig)
+
+	case "RS256":
+		if k.Kty != "RSA" {
+			return false
+		}
+		n, okN := decodeJwkInt(k.N, 0)
+		e, okE := decodeJwkInt(k.E, 0)
+		if !okN || !okE || n.BitLen() < 2048 || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return false
+		}
+		pub := &rsa.PublicKey{N: n, E: int(e.Int64())}
+		digest := sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+
+	default:
+		return false
+	}
+}
+
+// decodeJwkInt decodes a big-endian integer of the given byte length,
+// any length if 0.
+func decodeJwkInt(s string, size int) (*big.Int, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 || size > 0 && len(b) != size {
+		return nil, false
+	}
+	return new(big.Int).SetBytes(b), true
+}
+
+// thumbprint computes the RFC 7638 thumbprint of the verified key.
+// The members are base64url, so they need no JSON escaping.
+func (k *dpopJwk) thumbprint() string {
+	var canonical string
+	switch k.Kty {
+	case "EC":
+		canonical = `{"crv":"` + k.Crv + `","kty":"EC","x":"` + k.X + `","y":"` + k.Y + `"}`
+	case "OKP":
+		canonical = `{"crv":"` + k.Crv + `","kty":"OKP","x":"` + k.X + `"}`
+	case "RSA":
+		canonical = `{"e":"` + k.E + `","kty":"RSA","n":"` + k.N + `"}`
+	}
+	digest := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
@@ -0,0 +1,295 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kzmnbrs/actkn"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/render"
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/impl/session"
+)
+
+type testDpopProof struct {
+	method string
+	url    string
+	tokRaw []byte
+	iat    time.Time
+	jti    string
+}
+
+// sign encodes the proof signed with an Ed25519 or a P-256 key.
+func (p *testDpopProof) sign(t *testing.T, key any) (proof, jkt string) {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	var h dpopHeader
+	h.Typ = "dpop+jwt"
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		h.Alg = "EdDSA"
+		h.Jwk = dpopJwk{Kty: "OKP", Crv: "Ed25519", X: b64(key.Public().(ed25519.PublicKey))}
+	case *ecdsa.PrivateKey:
+		h.Alg = "ES256"
+		h.Jwk = dpopJwk{Kty: "EC", Crv: "P-256", X: b64(key.X.FillBytes(make([]byte, 32))), Y: b64(key.Y.FillBytes(make([]byte, 32)))}
+	}
+	c := dpopClaims{Jti: p.jti, Htm: p.method, Htu: p.url, Iat: p.iat.Unix()}
+	if p.tokRaw != nil {
+		ath := sha256.Sum256(p.tokRaw)
+		c.Ath = b64(ath[:])
+	}
+
+	hData, err := json.Marshal(h)
+	assert.Nil(t, err)
+	cData, err := json.Marshal(c)
+	assert.Nil(t, err)
+	signed := b64(hData) + "." + b64(cData)
+
+	var sig []byte
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(key, []byte(signed))
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		assert.Nil(t, err)
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64(sig), h.Jwk.thumbprint()
+}
+
+func TestDpop_Verify(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	now := time.Unix(1_000_000, 0)
+	valid := func() testDpopProof {
+		return testDpopProof{
+			method: "GET",
+			url:    "https://api.boris.army/sessions?cursor=1",
+			tokRaw: []byte("tok"),
+			iat:    now,
+			jti:    "e1j3V_bKic8-LAEB",
+		}
+	}
+
+	type tc struct {
+		name   string
+		key    any
+		mutate func(*testDpopProof)
+		tokRaw []byte
+		expErr error
+	}
+	tcs := []tc{
+		{"ed25519", edKey, func(*testDpopProof) {}, []byte("tok"), nil},
+		{"p-256", ecKey, func(*testDpopProof) {}, []byte("tok"), nil},
+		{"token request", edKey, func(p *testDpopProof) { p.method, p.tokRaw = "POST", nil }, nil, nil},
+		{"other method", edKey, func(p *testDpopProof) { p.method = "POST" }, []byte("tok"), domain.ErrValue},
+		{"other url", edKey, func(p *testDpopProof) { p.url = "https://evil.me/sessions" }, []byte("tok"), domain.ErrValue},
+		{"other token", edKey, func(p *testDpopProof) { p.tokRaw = []byte("kot") }, []byte("tok"), domain.ErrValue},
+		{"no jti", edKey, func(p *testDpopProof) { p.jti = "" }, []byte("tok"), domain.ErrValue},
+		{"expired", edKey, func(p *testDpopProof) { p.iat = now.Add(-time.Minute * 2) }, []byte("tok"), domain.ErrExpired},
+		{"future", edKey, func(p *testDpopProof) { p.iat = now.Add(time.Minute * 2) }, []byte("tok"), domain.ErrValue},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDpop("https://api.boris.army/", time.Minute)
+			d.now = func() time.Time { return now }
+
+			p := valid()
+			tc.mutate(&p)
+			proof, expJkt := p.sign(t, tc.key)
+
+			// Token requests are POST, the others GET.
+			req := fasthttp.RequestCtx{}
+			req.Request.Header.SetMethod("GET")
+			if tc.tokRaw == nil {
+				req.Request.Header.SetMethod("POST")
+			}
+			req.Request.SetRequestURI("/sessions?cursor=2")
+			req.Request.Header.Set("DPoP", proof)
+
+			jkt, err := d.Verify(&req, tc.tokRaw)
+			assert.Equal(t, tc.expErr, err)
+			if tc.expErr == nil {
+				assert.Equal(t, expJkt, jkt)
+				assert.Len(t, jkt, 43)
+
+				_, err := d.Verify(&req, tc.tokRaw)
+				assert.Equal(t, domain.ErrValue, err, "replay")
+			}
+		})
+	}
+}
+
+func TestDpop_Verify_Malformed(t *testing.T) {
+	d := NewDpop("", time.Minute)
+
+	req := fasthttp.RequestCtx{}
+	_, err := d.Verify(&req, nil)
+	assert.Equal(t, domain.ErrKey, err)
+
+	for _, proof := range []string{"foo", "a.b.c", "e30.e30.e30"} {
+		req.Request.Header.Set("DPoP", proof)
+		_, err := d.Verify(&req, nil)
+		assert.Equal(t, domain.ErrValue, err, proof)
+	}
+
+	var nilDpop *Dpop
+	_, err = nilDpop.Verify(&req, nil)
+	assert.Equal(t, domain.ErrKey, err)
+}
+
+func TestDpopJwk_thumbprint(t *testing.T) {
+	// RFC 7638, section 3.1.
+	k := dpopJwk{
+		Kty: "RSA",
+		E:   "AQAB",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", k.thumbprint())
+}
+
+func TestAccess_Apply_Dpop(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	proof, jkt := (&testDpopProof{
+		method: "GET",
+		url:    "https://api.boris.army/private",
+		tokRaw: []byte("tok"),
+		iat:    time.Now(),
+		jti:    "1",
+	}).sign(t, key)
+
+	type tc struct {
+		name       string
+		authHdr    string
+		proof      string
+		tokJkt     string
+		expResCode int
+		expResBody string
+	}
+	tcs := []tc{
+		{"bound", "DPoP tok", proof, jkt, fasthttp.StatusOK, `ok`},
+		{"bound as bearer", "Bearer tok", proof, jkt, fasthttp.StatusUnauthorized, `{"err":{"code":"` + render.CodeTokenInvalid + `"}}`},
+		{"unbound as dpop", "DPoP tok", proof, "", fasthttp.StatusUnauthorized, `{"err":{"code":"` + render.CodeTokenInvalid + `"}}`},
+		{"no proof", "DPoP tok", "", jkt, fasthttp.StatusUnauthorized, `{"err":{"code":"` + render.CodeDpopProofInvalid + `"}}`},
+		{"other key", "DPoP tok", proof, "Ak8FmsK5DRRuDiR5DWYjxXFQE6GdMXZ9OdJY4JF6e7Q", fasthttp.StatusUnauthorized, `{"err":{"code":"` + render.CodeDpopProofInvalid + `"}}`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sess := session.NewMockDriverSession(ctrl)
+			sess.EXPECT().DecodeHttpTokenTo(gomock.Any(), []byte("tok")).
+				DoAndReturn(func(dst *domain.SessionHttpToken, _ []byte) error {
+					dst.Jkt = tc.tokJkt
+					return nil
+				})
+
+			mw := Access{Sessions: sess, Dpop: NewDpop("https://api.boris.army", time.Minute)}
+			handler := mw.Apply(func(req *fasthttp.RequestCtx, _ *domain.SessionHttpToken) {
+				_, _ = req.WriteString("ok")
+			}, AllowAny)
+
+			req := fasthttp.RequestCtx{}
+			req.Request.SetRequestURI("/private")
+			req.Request.Header.Set(fasthttp.HeaderAuthorization, tc.authHdr)
+			if len(tc.proof) > 0 {
+				req.Request.Header.Set("DPoP", tc.proof)
+			}
+
+			handler(&req)
+			assert.Equal(t, tc.expResCode, req.Response.StatusCode())
+			assert.Equal(t, tc.expResBody, string(req.Response.Body()))
+		})
+	}
+}
+
+// TestAccess_Apply_DpopSessionDriver checks the proof against the
+// token as sent, the session driver decodes tokens in place.
+func TestAccess_Apply_DpopSessionDriver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	_, jkt := (&testDpopProof{}).sign(t, key)
+
+	repoSess := session.NewMockRepositorySession(ctrl)
+	repoSess.EXPECT().IsTerminated(int64(1), gomock.Any()).Return(false, nil)
+	sess := &session.Driver{
+		Sessions: repoSess,
+		Actkn:    actkn.NewManager("biWS2fEqV80PErLR6P-adQFhPhgfCM4zKS8hCpI0Pao"),
+	}
+
+	tokRaw, err := sess.EncodeHttpTokenTo(nil, &domain.SessionHttpToken{
+		SessionId: 1,
+		User:      domain.SessionHttpTokenUser{Id: 1},
+		Jkt:       jkt,
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	})
+	assert.Nil(t, err)
+	proof, _ := (&testDpopProof{
+		method: "GET",
+		url:    "https://api.boris.army/private",
+		tokRaw: tokRaw,
+		iat:    time.Now(),
+		jti:    "1",
+	}).sign(t, key)
+
+	mw := Access{Sessions: sess, Dpop: NewDpop("https://api.boris.army", time.Minute)}
+	handler := mw.Apply(func(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+		_, _ = req.WriteString(strconv.FormatInt(tok.User.Id, 10))
+	}, AllowAny)
+
+	req := fasthttp.RequestCtx{}
+	req.Request.SetRequestURI("/private")
+	req.Request.Header.Set(fasthttp.HeaderAuthorization, "DPoP "+string(tokRaw))
+	req.Request.Header.Set("DPoP", proof)
+
+	handler(&req)
+	assert.Equal(t, fasthttp.StatusOK, req.Response.StatusCode())
+	assert.Equal(t, "1", string(req.Response.Body()))
+}
+
+func TestAccess_getTokenRaw_Sources(t *testing.T) {
+	req := fasthttp.RequestCtx{}
+	req.Request.SetRequestURI("/private?access_token=query")
+	req.Request.Header.SetCookie("access_token", "cookie")
+
+	mw := &Access{Sources: TokenSourceHeader | TokenSourceCookie}
+	tokRaw, _, _ := mw.getTokenRaw(&req)
+	assert.Equal(t, "cookie", string(tokRaw))
+
+	tokRaw, _, _ = mw.WithSources(TokenSourceHeader).getTokenRaw(&req)
+	assert.Empty(t, tokRaw)
+	assert.Equal(t, TokenSourceHeader|TokenSourceCookie, mw.Sources)
+
+	req.Request.Header.Set(fasthttp.HeaderAuthorization, "dpop header")
+	tokRaw, _, isDpop := mw.getTokenRaw(&req)
+	assert.Equal(t, "header", string(tokRaw))
+	assert.True(t, isDpop)
+
+	sources, err := ParseTokenSources([]string{"header", "query"})
+	assert.Nil(t, err)
+	assert.Equal(t, TokenSourceHeader|TokenSourceQuery, sources)
+	_, err = ParseTokenSources([]string{"body"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+// RequireRole grants access to the tokens of users having every
+// given role.
+func RequireRole(roles domain.UserRole) AccessEnforcerFn {
+	return func(tok *domain.SessionHttpToken) bool {
+		return tok.User.Roles&roles == roles
+	}
+}
+
+// RequireScope grants access to the tokens good for every given scope.
+func RequireScope(scopes ...string) AccessEnforcerFn {
+	return func(tok *domain.SessionHttpToken) bool {
+		for _, scope := range scopes {
+			if !tok.HasScope(scope) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// RequireProof grants access to the tokens of users having every
+// given proof.
+func RequireProof(proofs domain.UserProof) AccessEnforcerFn {
+	return func(tok *domain.SessionHttpToken) bool {
+		return tok.User.HasProof&proofs == proofs
+	}
+}
+
+// AnyOf grants access if any of the enforcers does.
+func AnyOf(enforcerFns ...AccessEnforcerFn) AccessEnforcerFn {
+	return func(tok *domain.SessionHttpToken) bool {
+		for _, fn := range enforcerFns {
+			if fn(tok) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AllOf grants access if every enforcer does.
+func AllOf(enforcerFns ...AccessEnforcerFn) AccessEnforcerFn {
+	return func(tok *domain.SessionHttpToken) bool {
+		for _, fn := range enforcerFns {
+			if !fn(tok) {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+func TestEnforcers(t *testing.T) {
+	tok := &domain.SessionHttpToken{
+		User: domain.SessionHttpTokenUser{
+			Id:       1,
+			Roles:    domain.UserRoleReviewer,
+			HasProof: domain.UserProofEmail | domain.UserProofPhone,
+		},
+		Scopes: domain.UserRoleScopes(nil, domain.UserRoleReviewer),
+	}
+
+	type tc struct {
+		name       string
+		enforcerFn AccessEnforcerFn
+		expOk      bool
+	}
+	tcs := []tc{
+		{"role", RequireRole(domain.UserRoleReviewer), true},
+		{"missing role", RequireRole(domain.UserRoleReviewer | domain.UserRoleAdmin), false},
+		{"scope", RequireScope(domain.ScopeAccount, domain.ScopeAttestationsReview), true},
+		{"missing scope", RequireScope(domain.ScopeUsersAdmin), false},
+		{"proof", RequireProof(domain.UserProofPhone), true},
+		{"missing proof", RequireProof(domain.UserProofPhone | domain.UserProofBoris), false},
+		{"any of", AnyOf(RequireRole(domain.UserRoleAdmin), AllowUserIds(1)), true},
+		{"any of none", AnyOf(RequireRole(domain.UserRoleAdmin), AllowUserIds(2)), false},
+		{"all of", AllOf(RequireProof(domain.UserProofEmail), RequireScope(domain.ScopeAccount)), true},
+		{"all of but one", AllOf(RequireProof(domain.UserProofEmail), RequireRole(domain.UserRoleAdmin)), false},
+	}
+	for _, tc := range tcs {
+		assert.Equal(t, tc.expOk, tc.enforcerFn(tok), tc.name)
+	}
+}
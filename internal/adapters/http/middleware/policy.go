@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/render"
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+// PolicyFile is the JSON form of a Policy.
+type PolicyFile struct {
+	// Audit turns every rule into an audit one.
+	Audit bool         `json:"audit"`
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyRule guards the routes it matches on top of their own
+// enforcers. A token passes the rule if it has every listed role,
+// scope and proof, or if its user is listed in UserIds. A rule must
+// list a role, a scope, a proof or set OwnerArg.
+type PolicyRule struct {
+	// Method is matched exactly, empty or "*" matches any.
+	Method string `json:"method"`
+	// Route is matched exactly, a trailing "*" matches any suffix.
+	Route   string   `json:"route"`
+	Roles   []string `json:"roles"`
+	Scopes  []string `json:"scopes"`
+	Proofs  []string `json:"proofs"`
+	UserIds []int64  `json:"user_ids"`
+	// OwnerArg names the query arg that must hold the id of the
+	// token user, if set.
+	OwnerArg string `json:"owner_arg"`
+	// TokenSources override Access.Sources, see ParseTokenSources.
+	TokenSources []string `json:"token_sources"`
+	// Audit logs the requests the rule would deny and leaves them to
+	// the route enforcer.
+	Audit bool `json:"audit"`
+}
+
+var (
+	policyRoles = map[string]domain.UserRole{
+		"reviewer": domain.UserRoleReviewer,
+		"admin":    domain.UserRoleAdmin,
+	}
+	policyProofs = map[string]domain.UserProof{
+		"email": domain.UserProofEmail,
+		"phone": domain.UserProofPhone,
+		"boris": domain.UserProofBoris,
+	}
+)
+
+// Policy is the set of compiled rules. The first rule matching a route
+// in file order applies.
+//
+// A nil *Policy matches no route.
+type Policy struct {
+	rules []policyRule
+}
+
+type policyRule struct {
+	method     string
+	route      string
+	prefix     bool
+	enforcerFn AccessEnforcerFn
+	ownerArg   string
+	sources    TokenSource
+	audit      bool
+}
+
+// LoadPolicy reads and compiles the policy file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f PolicyFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("middleware: can't decode policy %s: %w", path, err)
+	}
+	return CompilePolicy(&f)
+}
+
+func CompilePolicy(f *PolicyFile) (*Policy, error) {
+	p := &Policy{rules: make([]policyRule, 0, len(f.Rules))}
+	for i := range f.Rules {
+		r, err := compilePolicyRule(&f.Rules[i])
+		if err != nil {
+			return nil, fmt.Errorf("middleware: policy rule %d: %w", i, err)
+		}
+		r.audit = r.audit || f.Audit
+		p.rules = append(p.rules, r)
+	}
+	return p, nil
+}
+
+func compilePolicyRule(src *PolicyRule) (policyRule, error) {
+	r := policyRule{
+		method:   src.Method,
+		route:    src.Route,
+		ownerArg: src.OwnerArg,
+		audit:    src.Audit,
+	}
+	if r.method == "*" {
+		r.method = ""
+	}
+	if strings.HasSuffix(r.route, "*") {
+		r.route = strings.TrimSuffix(r.route, "*")
+		r.prefix = true
+	}
+	if !strings.HasPrefix(r.route, "/") {
+		return r, fmt.Errorf("route must start with /")
+	}
+	if len(src.Roles) == 0 && len(src.Scopes) == 0 && len(src.Proofs) == 0 && len(src.OwnerArg) == 0 {
+		// UserIds alone would grant access to everyone.
+		return r, fmt.Errorf("rule must require a role, a scope, a proof or an owner")
+	}
+
+	sources, err := ParseTokenSources(src.TokenSources)
+	if err != nil {
+		return r, err
+	}
+	r.sources = sources
+
+	var roles domain.UserRole
+	for _, name := range src.Roles {
+		role, ok := policyRoles[name]
+		if !ok {
+			return r, fmt.Errorf("unknown role %q", name)
+		}
+		roles |= role
+	}
+	var proofs domain.UserProof
+	for _, name := range src.Proofs {
+		proof, ok := policyProofs[name]
+		if !ok {
+			return r, fmt.Errorf("unknown proof %q", name)
+		}
+		proofs |= proof
+	}
+
+	r.enforcerFn = AllOf(RequireRole(roles), RequireScope(src.Scopes...), RequireProof(proofs))
+	if len(src.UserIds) > 0 {
+		r.enforcerFn = AnyOf(r.enforcerFn, AllowUserIds(src.UserIds...))
+	}
+	return r, nil
+}
+
+func (p *Policy) match(method, route string) *policyRule {
+	if p == nil {
+		return nil
+	}
+	for i := range p.rules {
+		r := &p.rules[i]
+		if len(r.method) > 0 && r.method != method {
+			continue
+		}
+		if r.route == route || r.prefix && strings.HasPrefix(route, r.route) {
+			return r
+		}
+	}
+	return nil
+}
+
+// Apply guards the route with Access and fallbackFn, the route own
+// enforcer. The matching rule, if any, must grant access as well.
+func (p *Policy) Apply(access *Access, method, route string, next HandlerWithAccess, fallbackFn AccessEnforcerFn) fasthttp.RequestHandler {
+	r := p.match(method, route)
+	if r == nil {
+		return access.Apply(next, fallbackFn)
+	}
+
+	enforcerFn := AllOf(fallbackFn, r.enforcerFn)
+	if r.audit {
+		enforcerFn = func(tok *domain.SessionHttpToken) bool {
+			if !r.enforcerFn(tok) {
+				log.Println("middleware: policy would deny", method, route, "to user", tok.User.Id)
+			}
+			return fallbackFn(tok)
+		}
+	}
+	if len(r.ownerArg) > 0 {
+		next = r.checkOwner(method, route, next)
+	}
+	if r.sources != 0 {
+		access = access.WithSources(r.sources)
+	}
+	return access.Apply(next, enforcerFn)
+}
+
+func (r *policyRule) checkOwner(method, route string, next HandlerWithAccess) HandlerWithAccess {
+	return func(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+		if !r.isOwner(req.QueryArgs(), tok) {
+			if !r.audit {
+				render.ErrAccessTokenInsufficient(req)
+				return
+			}
+			log.Println("middleware: policy would deny", method, route, "to non-owner", tok.User.Id)
+		}
+		next(req, tok)
+	}
+}
+
+func (r *policyRule) isOwner(args *fasthttp.Args, tok *domain.SessionHttpToken) bool {
+	ownerId, err := strconv.ParseInt(string(args.Peek(r.ownerArg)), 10, 64)
+	return err == nil && ownerId == tok.User.Id
+}
+
+// Evaluate tells whether the policy grants the request to the token,
+// regardless of audit mode and of the route enforcer. It is meant for
+// testing policy files against synthetic tokens:
+//
+//	ok, matched := p.Evaluate("GET", "/admin/attestations?user_id=1", tok)
+func (p *Policy) Evaluate(method, uri string, tok *domain.SessionHttpToken) (ok, matched bool) {
+	var req fasthttp.Request
+	req.SetRequestURI(uri)
+	u := req.URI()
+
+	r := p.match(method, string(u.Path()))
+	if r == nil {
+		return false, false
+	}
+	if !r.enforcerFn(tok) {
+		return false, true
+	}
+	if len(r.ownerArg) > 0 && !r.isOwner(u.QueryArgs(), tok) {
+		return false, true
+	}
+	return true, true
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/render"
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/impl/session"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	const data = `
+		{
+			"rules": [
+				{"route": "/admin/*", "roles": ["reviewer"], "user_ids": [7]},
+				{"method": "GET", "route": "/users/attestations", "proofs": ["email"], "owner_arg": "user_id"},
+				{"method": "POST", "route": "/users/me/attestations", "scopes": ["account"], "proofs": ["phone"]}
+			]
+		}
+	`
+	assert.Nil(t, os.WriteFile(path, []byte(data), 0600))
+
+	p, err := LoadPolicy(path)
+	assert.Nil(t, err)
+
+	reviewer := &domain.SessionHttpToken{User: domain.SessionHttpTokenUser{Id: 1, Roles: domain.UserRoleReviewer}}
+	listed := &domain.SessionHttpToken{User: domain.SessionHttpTokenUser{Id: 7}}
+	user := &domain.SessionHttpToken{
+		User:   domain.SessionHttpTokenUser{Id: 2, HasProof: domain.UserProofEmail},
+		Scopes: []string{domain.ScopeAccount},
+	}
+
+	type tc struct {
+		name       string
+		method     string
+		uri        string
+		tok        *domain.SessionHttpToken
+		expOk      bool
+		expMatched bool
+	}
+	tcs := []tc{
+		{"reviewer", "GET", "/admin/attestations", reviewer, true, true},
+		{"listed user", "POST", "/admin/attestations/decision", listed, true, true},
+		{"not reviewer", "GET", "/admin/attestations", user, false, true},
+		{"owner", "GET", "/users/attestations?user_id=2", user, true, true},
+		{"not owner", "GET", "/users/attestations?user_id=1", user, false, true},
+		{"no owner arg", "GET", "/users/attestations", user, false, true},
+		{"missing proof", "POST", "/users/me/attestations", user, false, true},
+		{"other method", "DELETE", "/users/attestations", user, false, false},
+		{"no rule", "GET", "/sessions", user, false, false},
+	}
+	for _, tc := range tcs {
+		ok, matched := p.Evaluate(tc.method, tc.uri, tc.tok)
+		assert.Equal(t, tc.expOk, ok, tc.name)
+		assert.Equal(t, tc.expMatched, matched, tc.name)
+	}
+}
+
+func TestCompilePolicy_Invalid(t *testing.T) {
+	tcs := []PolicyRule{
+		{Route: "admin"},
+		{Route: "/admin", Roles: []string{"root"}},
+		{Route: "/admin", Proofs: []string{"passport"}},
+		{Route: "/admin"},
+		{Route: "/admin", UserIds: []int64{7}},
+	}
+	for _, tc := range tcs {
+		_, err := CompilePolicy(&PolicyFile{Rules: []PolicyRule{tc}})
+		assert.NotNil(t, err, tc)
+	}
+}
+
+func TestPolicy_Apply(t *testing.T) {
+	type tc struct {
+		name       string
+		audit      bool
+		uri        string
+		fallbackFn AccessEnforcerFn
+		expResCode int
+	}
+	tcs := []tc{
+		{"denied", false, "/admin?user_id=1", AllowAny, fasthttp.StatusForbidden},
+		{"audit", true, "/admin?user_id=1", AllowAny, fasthttp.StatusOK},
+		{"not owner", false, "/admin?user_id=2", AllowAny, fasthttp.StatusForbidden},
+		{"audit not owner", true, "/admin?user_id=2", AllowAny, fasthttp.StatusOK},
+		{"owner", false, "/admin?user_id=2&owner", AllowAny, fasthttp.StatusOK},
+		{"owner denied by route", false, "/admin?user_id=2&owner", RequireScope("users:admin"), fasthttp.StatusForbidden},
+		{"audit denied by route", true, "/admin?user_id=1", RequireScope("users:admin"), fasthttp.StatusForbidden},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sess := session.NewMockDriverSession(ctrl)
+			sess.EXPECT().DecodeHttpTokenTo(gomock.Any(), []byte("tok")).
+				DoAndReturn(func(dst *domain.SessionHttpToken, _ []byte) error {
+					dst.User.Id = 1
+					if tc.uri == "/admin?user_id=2&owner" {
+						dst.User.Id = 2
+					}
+					return nil
+				})
+
+			rule := PolicyRule{Route: "/admin", OwnerArg: "user_id"}
+			if tc.uri == "/admin?user_id=1" {
+				rule.Roles = []string{"admin"}
+			}
+			p, err := CompilePolicy(&PolicyFile{Audit: tc.audit, Rules: []PolicyRule{rule}})
+			assert.Nil(t, err)
+
+			handler := p.Apply(&Access{Sessions: sess}, fasthttp.MethodGet, "/admin", func(req *fasthttp.RequestCtx, _ *domain.SessionHttpToken) {
+				_, _ = req.WriteString("ok")
+			}, tc.fallbackFn)
+
+			req := fasthttp.RequestCtx{}
+			req.Request.SetRequestURI(tc.uri)
+			req.Request.Header.Set(fasthttp.HeaderAuthorization, "Bearer tok")
+			handler(&req)
+
+			assert.Equal(t, tc.expResCode, req.Response.StatusCode())
+			if tc.expResCode == fasthttp.StatusForbidden {
+				assert.Equal(t, `{"err":{"code":"`+render.CodeTokenInsufficient+`"}}`, string(req.Response.Body()))
+			}
+		})
+	}
+}
+
+func TestPolicy_Apply_Fallback(t *testing.T) {
+	var p *Policy
+	handler := p.Apply(&Access{}, fasthttp.MethodGet, "/sessions", func(req *fasthttp.RequestCtx, _ *domain.SessionHttpToken) {
+		_, _ = req.WriteString("ok")
+	}, AllowAny)
+
+	req := fasthttp.RequestCtx{}
+	handler(&req)
+	assert.Equal(t, fasthttp.StatusUnauthorized, req.Response.StatusCode())
+}
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/render"
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+// RateLimitKeyFn derives the bucket key of the request. The token is
+// nil unless the limit is applied behind Access. Requests with an empty
+// key are not limited.
+type RateLimitKeyFn = func(*fasthttp.RequestCtx, *domain.SessionHttpToken) string
+
+// KeyByIp shares a bucket between the requests of a client address,
+// see domain.ClientAddr.
+func KeyByIp(req *fasthttp.RequestCtx, _ *domain.SessionHttpToken) string {
+	return domain.ClientAddr(req.RemoteIP())
+}
+
+// KeyByUserId shares a bucket between the requests of a user.
+func KeyByUserId(_ *fasthttp.RequestCtx, tok *domain.SessionHttpToken) string {
+	if tok == nil {
+		return ""
+	}
+	return strconv.FormatInt(tok.User.Id, 10)
+}
+
+// KeyByRoute shares a bucket between the requests to an endpoint.
+func KeyByRoute(req *fasthttp.RequestCtx, _ *domain.SessionHttpToken) string {
+	return string(req.Method()) + " " + string(req.Path())
+}
+
+// KeyJoin combines the keys, e.g. KeyJoin(KeyByRoute, KeyByIp) gives
+// every client address a bucket per endpoint.
+func KeyJoin(keyFns ...RateLimitKeyFn) RateLimitKeyFn {
+	return func(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) string {
+		var key string
+		for i, keyFn := range keyFns {
+			k := keyFn(req, tok)
+			if len(k) == 0 {
+				return ""
+			}
+			if i > 0 {
+				key += "|"
+			}
+			key += k
+		}
+		return key
+	}
+}
+
+// RateLimit is a token bucket limiter. Every key gets Burst requests
+// at once, refilled at Rate requests per second. Buckets live in the
+// memory of the instance, so the limits apply per instance.
+//
+// A nil *RateLimit passes every request through.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+	KeyFn RateLimitKeyFn
+
+	mu       sync.Mutex
+	buckets  map[string]*rateBucket
+	purgedAt time.Time
+	now      func() time.Time
+}
+
+type rateBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+func NewRateLimit(rate float64, burst int, keyFn RateLimitKeyFn) *RateLimit {
+	return &RateLimit{
+		Rate:    rate,
+		Burst:   burst,
+		KeyFn:   keyFn,
+		buckets: make(map[string]*rateBucket),
+		now:     time.Now,
+	}
+}
+
+// Apply limits the requests in front of Access or of a public endpoint.
+func (m *RateLimit) Apply(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	if m == nil {
+		return next
+	}
+	return func(req *fasthttp.RequestCtx) {
+		if m.allow(req, nil) {
+			next(req)
+		}
+	}
+}
+
+// ApplyWithAccess limits the requests behind Access, so the key
+// function can rely on the token:
+//
+//	access.Apply(userLimit.ApplyWithAccess(handler), enforcerFn)
+func (m *RateLimit) ApplyWithAccess(next HandlerWithAccess) HandlerWithAccess {
+	if m == nil {
+		return next
+	}
+	return func(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+		if m.allow(req, tok) {
+			next(req, tok)
+		}
+	}
+}
+
+// allow takes a token from the request bucket and sets the RateLimit-*
+// headers. The rejected requests are rendered as render.ErrRateLimited.
+func (m *RateLimit) allow(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) bool {
+	key := m.KeyFn(req, tok)
+	if len(key) == 0 {
+		return true
+	}
+
+	remaining, ok := m.take(key)
+
+	h := &req.Response.Header
+	h.Set("RateLimit-Limit", strconv.Itoa(m.Burst))
+	h.Set("RateLimit-Remaining", strconv.Itoa(int(remaining)))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(m.refillIn(remaining, float64(m.Burst)))))
+	if !ok {
+		render.ErrRateLimited(req, m.refillIn(remaining, 1))
+		return false
+	}
+	return true
+}
+
+// take refills the bucket of the key and takes a token from it.
+// It returns the tokens left.
+func (m *RateLimit) take(key string) (float64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.purge(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &rateBucket{tokens: float64(m.Burst)}
+		m.buckets[key] = b
+	} else {
+		b.tokens = math.Min(float64(m.Burst), b.tokens+now.Sub(b.updatedAt).Seconds()*m.Rate)
+	}
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		return b.tokens, false
+	}
+	b.tokens--
+	return b.tokens, true
+}
+
+// purge drops the buckets refilled to Burst, they are no different
+// from the missing ones. It runs once per full refill period.
+func (m *RateLimit) purge(now time.Time) {
+	refill := m.refillIn(0, float64(m.Burst))
+	if now.Sub(m.purgedAt) < refill {
+		return
+	}
+	for key, b := range m.buckets {
+		if now.Sub(b.updatedAt) >= refill {
+			delete(m.buckets, key)
+		}
+	}
+	m.purgedAt = now
+}
+
+// refillIn returns the time for the bucket to grow from tokens to want.
+func (m *RateLimit) refillIn(tokens, want float64) time.Duration {
+	if tokens >= want {
+		return 0
+	}
+	return time.Duration((want - tokens) / m.Rate * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/render"
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+func TestRateLimit_Apply(t *testing.T) {
+	now := time.Unix(1000, 0)
+	mw := NewRateLimit(.5, 2, KeyByIp)
+	mw.now = func() time.Time { return now }
+
+	handler := mw.Apply(func(req *fasthttp.RequestCtx) {
+		_, _ = req.WriteString("ok")
+	})
+
+	type tc struct {
+		name         string
+		advance      time.Duration
+		expResCode   int
+		expRemaining string
+		expReset     string
+		expRetry     string
+	}
+	tcs := []tc{
+		{"first", 0, fasthttp.StatusOK, "1", "2", ""},
+		{"second", 0, fasthttp.StatusOK, "0", "4", ""},
+		{"empty", 0, fasthttp.StatusTooManyRequests, "0", "4", "2"},
+		{"half refilled", time.Second, fasthttp.StatusTooManyRequests, "0", "3", "1"},
+		{"refilled", time.Second, fasthttp.StatusOK, "0", "4", ""},
+	}
+	for _, tc := range tcs {
+		now = now.Add(tc.advance)
+
+		req := fasthttp.RequestCtx{}
+		handler(&req)
+
+		h := &req.Response.Header
+		assert.Equal(t, tc.expResCode, req.Response.StatusCode(), tc.name)
+		assert.Equal(t, "2", string(h.Peek("RateLimit-Limit")), tc.name)
+		assert.Equal(t, tc.expRemaining, string(h.Peek("RateLimit-Remaining")), tc.name)
+		assert.Equal(t, tc.expReset, string(h.Peek("RateLimit-Reset")), tc.name)
+		assert.Equal(t, tc.expRetry, string(h.Peek("Retry-After")), tc.name)
+		if tc.expResCode == fasthttp.StatusTooManyRequests {
+			assert.Equal(t, `{"err":{"code":"`+render.CodeRateLimited+`"}}`, string(req.Response.Body()), tc.name)
+		}
+	}
+}
+
+func TestRateLimit_ApplyWithAccess(t *testing.T) {
+	mw := NewRateLimit(1, 1, KeyByUserId)
+	handler := mw.ApplyWithAccess(func(req *fasthttp.RequestCtx, _ *domain.SessionHttpToken) {
+		_, _ = req.WriteString("ok")
+	})
+
+	serve := func(userId int64) int {
+		req := fasthttp.RequestCtx{}
+		handler(&req, &domain.SessionHttpToken{User: domain.SessionHttpTokenUser{Id: userId}})
+		return req.Response.StatusCode()
+	}
+	assert.Equal(t, fasthttp.StatusOK, serve(1))
+	assert.Equal(t, fasthttp.StatusTooManyRequests, serve(1))
+	assert.Equal(t, fasthttp.StatusOK, serve(2))
+}
+
+func TestRateLimit_Nil(t *testing.T) {
+	var mw *RateLimit
+	handler := mw.Apply(func(req *fasthttp.RequestCtx) {
+		_, _ = req.WriteString("ok")
+	})
+
+	req := fasthttp.RequestCtx{}
+	handler(&req)
+	assert.Equal(t, "ok", string(req.Response.Body()))
+	assert.Empty(t, req.Response.Header.Peek("RateLimit-Limit"))
+}
+
+func TestRateLimit_purge(t *testing.T) {
+	now := time.Unix(1000, 0)
+	mw := NewRateLimit(1, 2, KeyByIp)
+	mw.now = func() time.Time { return now }
+
+	mw.take("a")
+	now = now.Add(time.Second)
+	mw.take("b")
+	assert.Len(t, mw.buckets, 2)
+
+	now = now.Add(time.Second)
+	mw.take("c")
+	assert.Len(t, mw.buckets, 2)
+	assert.NotContains(t, mw.buckets, "a")
+}
+
+func TestKeyJoin(t *testing.T) {
+	req := fasthttp.RequestCtx{}
+	req.Request.Header.SetMethod(fasthttp.MethodPost)
+	req.Request.SetRequestURI("/sessions")
+
+	assert.Equal(t, "POST /sessions|0.0.0.0", KeyJoin(KeyByRoute, KeyByIp)(&req, nil))
+	assert.Equal(t, "", KeyJoin(KeyByRoute, KeyByUserId)(&req, nil))
+}
+
+func TestKeyByIp(t *testing.T) {
+	req := fasthttp.RequestCtx{}
+	req.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("2001:db8::1")})
+	assert.Equal(t, "2001:db8::", KeyByIp(&req, nil))
+
+	// The same /64.
+	req.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("2001:db8::abcd:1")})
+	assert.Equal(t, "2001:db8::", KeyByIp(&req, nil))
+
+	req.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	assert.Equal(t, "127.0.0.1", KeyByIp(&req, nil))
+}
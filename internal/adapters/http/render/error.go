@@ -1,23 +1,54 @@
 package render
 
-import "github.com/valyala/fasthttp"
+import (
+	"strconv"
+	"time"
 
-const (
-	CodeValue             = "VALUE"
-	CodeUserExists        = "USER_EXISTS"
-	CodeInternal          = "INTERNAL"
-	CodeTokenRequired     = "ACCESS_TOKEN_REQUIRED"
-	CodeTokenInvalid      = "ACCESS_TOKEN_INVALID"
-	CodeTokenExpired      = "ACCESS_TOKEN_EXPIRED"
-	CodeTokenRevoked      = "ACCESS_TOKEN_REVOKED"
-	CodeTokenInsufficient = "FORBIDDEN"
+	"github.com/valyala/fasthttp"
 )
 
 const (
-	authRealm   = "boris.army"
-	authPrelude = `Bearer authRealm="` + authRealm + `"`
+	CodeValue              = "VALUE"
+	CodeUserExists         = "USER_EXISTS"
+	CodeAttestationExists  = "ATTESTATION_EXISTS"
+	CodeInternal           = "INTERNAL"
+	CodeNotFound           = "NOT_FOUND"
+	CodeMethodNotAllowed   = "METHOD_NOT_ALLOWED"
+	CodeCredentialsInvalid = "CREDENTIALS_INVALID"
+	CodeThrottled          = "THROTTLED"
+	CodeLoginLocked        = "LOGIN_LOCKED"
+	CodeRateLimited        = "RATE_LIMITED"
+	CodeDpopProofInvalid   = "DPOP_PROOF_INVALID"
+	CodeCsrfInvalid        = "CSRF_TOKEN_INVALID"
+	CodeOverloaded         = "OVERLOADED"
+	CodeConfirmInvalid     = "CONFIRMATION_INVALID"
+	CodeConfirmExpired     = "CONFIRMATION_EXPIRED"
+	CodeResetInvalid       = "PASSWORD_RESET_INVALID"
+	CodeResetExpired       = "PASSWORD_RESET_EXPIRED"
+	CodeOtpInvalid         = "OTP_INVALID"
+	CodeOtpExpired         = "OTP_EXPIRED"
+	CodeChallengeExpired   = "LOGIN_CHALLENGE_EXPIRED"
+	CodeTotpEnabled        = "TOTP_ENABLED"
+	CodeTotpNotEnrolled    = "TOTP_NOT_ENROLLED"
+	CodeTokenRequired      = "ACCESS_TOKEN_REQUIRED"
+	CodeTokenInvalid       = "ACCESS_TOKEN_INVALID"
+	CodeTokenExpired       = "ACCESS_TOKEN_EXPIRED"
+	CodeTokenRevoked       = "ACCESS_TOKEN_REVOKED"
+	CodeTokenInsufficient  = "FORBIDDEN"
+)
+
+var (
+	authPrelude = `Bearer authRealm="boris.army"`
+	dpopPrelude = `DPoP authRealm="boris.army"`
 )
 
+// SetAuthRealm replaces the realm advertised in WWW-Authenticate.
+// Must be called before serving any request.
+func SetAuthRealm(realm string) {
+	authPrelude = `Bearer authRealm="` + realm + `"`
+	dpopPrelude = `DPoP authRealm="` + realm + `"`
+}
+
 func ErrBadReq(w *fasthttp.RequestCtx, code, mes string) {
 	w.SetStatusCode(fasthttp.StatusBadRequest)
 	Err(w, code, mes)
@@ -28,11 +59,63 @@ func ErrConflict(w *fasthttp.RequestCtx, code, mes string) {
 	Err(w, code, mes)
 }
 
+func ErrGone(w *fasthttp.RequestCtx, code, mes string) {
+	w.SetStatusCode(fasthttp.StatusGone)
+	Err(w, code, mes)
+}
+
+// ErrThrottled asks the client to retry the request after the given
+// delay, rounded up to seconds.
+func ErrThrottled(w *fasthttp.RequestCtx, retryAfter time.Duration) {
+	w.SetStatusCode(fasthttp.StatusTooManyRequests)
+	setRetryAfter(w, retryAfter)
+	Err(w, CodeThrottled, "")
+}
+
+// ErrLoginLocked tells the client that logins are locked after too
+// many failures, until the given delay passes.
+func ErrLoginLocked(w *fasthttp.RequestCtx, retryAfter time.Duration) {
+	w.SetStatusCode(fasthttp.StatusTooManyRequests)
+	setRetryAfter(w, retryAfter)
+	Err(w, CodeLoginLocked, "")
+}
+
+// ErrRateLimited rejects a request over the client rate limit until
+// the given delay passes.
+func ErrRateLimited(w *fasthttp.RequestCtx, retryAfter time.Duration) {
+	w.SetStatusCode(fasthttp.StatusTooManyRequests)
+	setRetryAfter(w, retryAfter)
+	Err(w, CodeRateLimited, "")
+}
+
+// ErrOverloaded asks the client to retry the request after the given
+// delay, rounded up to seconds.
+func ErrOverloaded(w *fasthttp.RequestCtx, retryAfter time.Duration) {
+	w.SetStatusCode(fasthttp.StatusServiceUnavailable)
+	setRetryAfter(w, retryAfter)
+	Err(w, CodeOverloaded, "")
+}
+
 func ErrInternal(w *fasthttp.RequestCtx, mes string) {
 	w.SetStatusCode(fasthttp.StatusInternalServerError)
 	Err(w, CodeInternal, mes)
 }
 
+func ErrNotFound(w *fasthttp.RequestCtx) {
+	w.SetStatusCode(fasthttp.StatusNotFound)
+	Err(w, CodeNotFound, "")
+}
+
+func ErrMethodNotAllowed(w *fasthttp.RequestCtx) {
+	w.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+	Err(w, CodeMethodNotAllowed, "")
+}
+
+func ErrCredentialsInvalid(w *fasthttp.RequestCtx) {
+	w.SetStatusCode(fasthttp.StatusUnauthorized)
+	Err(w, CodeCredentialsInvalid, "")
+}
+
 func Err(w *fasthttp.RequestCtx, code, mes string) {
 	w.SetContentType("application/json")
 	_, _ = w.WriteString(`{"err":{"code":"`)
@@ -68,6 +151,22 @@ func ErrAccessTokenRevoked(w *fasthttp.RequestCtx) {
 	Err(w, CodeTokenRevoked, "")
 }
 
+// ErrDpopProofInvalid rejects a request lacking a valid DPoP proof
+// (RFC 9449) of the client key its token is bound to.
+func ErrDpopProofInvalid(w *fasthttp.RequestCtx) {
+	w.SetStatusCode(fasthttp.StatusUnauthorized)
+	w.Response.Header.Set(fasthttp.HeaderWWWAuthenticate, dpopPrelude)
+	w.Response.Header.Add(fasthttp.HeaderWWWAuthenticate, `error="invalid_dpop_proof"`)
+	Err(w, CodeDpopProofInvalid, "")
+}
+
+// ErrCsrfInvalid rejects an unsafe request authenticated by the cookie
+// that lacks the CSRF token of the session or comes from a foreign origin.
+func ErrCsrfInvalid(w *fasthttp.RequestCtx) {
+	w.SetStatusCode(fasthttp.StatusForbidden)
+	Err(w, CodeCsrfInvalid, "")
+}
+
 func ErrAccessTokenInsufficient(w *fasthttp.RequestCtx) {
 	w.SetStatusCode(fasthttp.StatusForbidden)
 	w.Response.Header.Add(fasthttp.HeaderWWWAuthenticate, authPrelude)
@@ -84,3 +183,11 @@ func setInvalidTokenHeaders(w *fasthttp.RequestCtx) {
 	setNoTokenHeaders(w)
 	w.Response.Header.Add(fasthttp.HeaderWWWAuthenticate, `error="invalid_token"`)
 }
+
+func setRetryAfter(w *fasthttp.RequestCtx, d time.Duration) {
+	secs := int64((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	w.Response.Header.Set(fasthttp.HeaderRetryAfter, strconv.FormatInt(secs, 10))
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/render"
+)
+
+// Router is an exact-match request multiplexer. Routes are looked up
+// by path first and by method second, so a known path with an unknown
+// method yields 405 instead of 404.
+type Router struct {
+	routes map[string]map[string]fasthttp.RequestHandler
+}
+
+func (r *Router) Handle(method, path string, handler fasthttp.RequestHandler) {
+	if r.routes == nil {
+		r.routes = make(map[string]map[string]fasthttp.RequestHandler)
+	}
+	byMethod, ok := r.routes[path]
+	if !ok {
+		byMethod = make(map[string]fasthttp.RequestHandler)
+		r.routes[path] = byMethod
+	}
+	byMethod[method] = handler
+}
+
+func (r *Router) Handler(req *fasthttp.RequestCtx) {
+	byMethod, ok := r.routes[string(req.Path())]
+	if !ok {
+		render.ErrNotFound(req)
+		return
+	}
+
+	handler, ok := byMethod[string(req.Method())]
+	if !ok {
+		render.ErrMethodNotAllowed(req)
+		return
+	}
+
+	handler(req)
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/render"
+)
+
+func TestRouter_Handler(t *testing.T) {
+	type tc struct {
+		name       string
+		method     string
+		path       string
+		expResCode int
+		expResBody string
+	}
+	tcs := []tc{
+		{"ok", fasthttp.MethodPost, "/users", fasthttp.StatusOK, `ok`},
+		{"not found", fasthttp.MethodPost, "/nope", fasthttp.StatusNotFound, `{"err":{"code":"` + render.CodeNotFound + `"}}`},
+		{"method not allowed", fasthttp.MethodGet, "/users", fasthttp.StatusMethodNotAllowed, `{"err":{"code":"` + render.CodeMethodNotAllowed + `"}}`},
+	}
+
+	r := Router{}
+	r.Handle(fasthttp.MethodPost, "/users", func(req *fasthttp.RequestCtx) {
+		_, _ = req.WriteString("ok")
+	})
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &fasthttp.RequestCtx{}
+			req.Request.Header.SetMethod(tc.method)
+			req.Request.SetRequestURI(tc.path)
+
+			r.Handler(req)
+			assert.Equal(t, tc.expResCode, req.Response.StatusCode())
+			assert.Equal(t, tc.expResBody, string(req.Response.Body()))
+		})
+	}
+}
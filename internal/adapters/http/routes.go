@@ -0,0 +1,40 @@
+package http
+
+import (
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/middleware"
+)
+
+// Mount registers every adapter endpoint on the given router.
+func (a *Adapter) Mount(r *Router) {
+	r.Handle(fasthttp.MethodPost, "/users", a.UserPost)
+	r.Handle(fasthttp.MethodPost, "/users/email-confirmation", a.UserEmailConfirmationPost)
+	r.Handle(fasthttp.MethodPost, "/users/email-confirmation/confirm", a.UserEmailConfirmationConfirmPost)
+	r.Handle(fasthttp.MethodPost, "/users/password-reset", a.LoginLimit.Apply(a.UserPasswordResetPost))
+	r.Handle(fasthttp.MethodPost, "/users/password-reset/confirm", a.LoginLimit.Apply(a.UserPasswordResetConfirmPost))
+	a.handleWithAccess(r, fasthttp.MethodPost, "/users/me/password", a.UserPasswordPost, middleware.AllowAny)
+	a.handleWithAccess(r, fasthttp.MethodPost, "/users/me/totp", a.UserTotpPost, middleware.AllowAny)
+	a.handleWithAccess(r, fasthttp.MethodDelete, "/users/me/totp", a.UserTotpDelete, middleware.AllowAny)
+	a.handleWithAccess(r, fasthttp.MethodPost, "/users/me/totp/confirm", a.UserTotpConfirmPost, middleware.AllowAny)
+	a.handleWithAccess(r, fasthttp.MethodGet, "/users/me/totp/recovery-codes", a.UserRecoveryCodesGet, middleware.AllowAny)
+	a.handleWithAccess(r, fasthttp.MethodPost, "/users/me/totp/recovery-codes", a.UserRecoveryCodesPost, middleware.AllowAny)
+	a.handleWithAccess(r, fasthttp.MethodPost, "/users/me/phone", a.UserPhonePost, middleware.AllowAny)
+	a.handleWithAccess(r, fasthttp.MethodPost, "/users/me/phone/verify", a.UserPhoneVerifyPost, middleware.AllowAny)
+	a.handleWithAccess(r, fasthttp.MethodPost, "/users/me/attestations", a.AttestationPost, middleware.AllowAny)
+	r.Handle(fasthttp.MethodPost, "/sessions", a.LoginLimit.Apply(a.SessionPost))
+	r.Handle(fasthttp.MethodPost, "/sessions/totp", a.LoginLimit.Apply(a.SessionTotpPost))
+	a.handleWithAccess(r, fasthttp.MethodGet, "/sessions", a.SessionsGet, middleware.AllowAny)
+	a.handleWithAccess(r, fasthttp.MethodDelete, "/sessions", a.SessionsDelete, middleware.AllowAny)
+	a.handleWithAccess(r, fasthttp.MethodDelete, "/sessions/current", a.SessionCurrentDelete, middleware.AllowAny)
+
+	a.handleWithAccess(r, fasthttp.MethodGet, "/admin/attestations", a.AdminAttestationsGet, a.Reviewers)
+	a.handleWithAccess(r, fasthttp.MethodPost, "/admin/attestations/decision", a.AdminAttestationDecisionPost, a.Reviewers)
+}
+
+// handleWithAccess registers the handler guarded by Access and the user
+// rate limit. The policy rule matching the route, if any, applies on
+// top of enforcerFn.
+func (a *Adapter) handleWithAccess(r *Router, method, path string, next middleware.HandlerWithAccess, enforcerFn middleware.AccessEnforcerFn) {
+	r.Handle(method, path, a.Policy.Apply(a.Access, method, path, a.UserLimit.ApplyWithAccess(next), enforcerFn))
+}
@@ -0,0 +1,394 @@
+package http
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/render"
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/core/ports"
+)
+
+//go:generate easyjson $GOFILE
+
+//easyjson:json
+type SessionPostCtx struct {
+	Email         string                         `json:"email,nocopy"`
+	Password      string                         `json:"password,nocopy"`
+	Cookie        bool                           `json:"cookie"`
+	Authenticate  ports.CommandUserAuthenticate  `json:"-"`
+	CreateSession ports.CommandSessionHttpCreate `json:"-"`
+}
+
+func (r *SessionPostCtx) Reset() {
+	r.Email = ""
+	r.Password = ""
+	r.Cookie = false
+	r.Authenticate.Reset()
+	r.CreateSession.Reset()
+}
+
+var sessionPostCtxPool = sync.Pool{
+	New: func() any {
+		return &SessionPostCtx{}
+	},
+}
+
+func (a *Adapter) SessionPost(req *fasthttp.RequestCtx) {
+	ctx := sessionPostCtxPool.Get().(*SessionPostCtx)
+	defer func() {
+		ctx.Reset()
+		sessionPostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	jkt, ok := a.verifyDpop(req)
+	if !ok || !a.checkCookieWanted(req, ctx.Cookie, jkt) {
+		return
+	}
+
+	auth := &ctx.Authenticate
+	auth.Email = ctx.Email
+	auth.Password = ctx.Password
+	auth.IpAddr = req.RemoteIP()
+	if err := a.Users.Authenticate(auth); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		case domain.ErrCredentials:
+			render.ErrCredentialsInvalid(req)
+			return
+
+		case domain.ErrSecondFactor:
+			writeLoginChallenge(req, auth.Challenge.Token, auth.Challenge.ExpiresAt.Unix())
+			return
+
+		case domain.ErrThrottled:
+			render.ErrLoginLocked(req, auth.RetryAfter)
+			return
+
+		case domain.ErrOverloaded:
+			render.ErrOverloaded(req, a.OverloadRetryAfter)
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	cmd := &ctx.CreateSession
+	cmd.UsedId = auth.Result.Id
+	cmd.UserEmail = auth.Result.Email
+	cmd.UserRoles = auth.Result.Roles
+	cmd.UserProof = auth.Result.HasProof
+	cmd.Jkt = jkt
+	cmd.IpAddr = req.RemoteIP()
+	cmd.UserAgent = string(req.UserAgent())
+	if err := a.Sessions.CreateHttp(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	if ctx.Cookie {
+		a.Cookie.write(req, &cmd.Result.Token, cmd.Result.TokenRaw)
+		return
+	}
+	writeSessionToken(req, &cmd.Result.Token, cmd.Result.TokenRaw)
+}
+
+//easyjson:json
+type SessionTotpPostCtx struct {
+	Challenge     string                         `json:"challenge,nocopy"`
+	Code          string                         `json:"code,nocopy"`
+	Cookie        bool                           `json:"cookie"`
+	LoginTotp     ports.CommandUserLoginTotp     `json:"-"`
+	CreateSession ports.CommandSessionHttpCreate `json:"-"`
+}
+
+func (r *SessionTotpPostCtx) Reset() {
+	r.Challenge = ""
+	r.Code = ""
+	r.Cookie = false
+	r.LoginTotp.Reset()
+	r.CreateSession.Reset()
+}
+
+var sessionTotpPostCtxPool = sync.Pool{
+	New: func() any {
+		return &SessionTotpPostCtx{}
+	},
+}
+
+// SessionTotpPost completes the login challenged by SessionPost
+// with a TOTP code.
+func (a *Adapter) SessionTotpPost(req *fasthttp.RequestCtx) {
+	ctx := sessionTotpPostCtxPool.Get().(*SessionTotpPostCtx)
+	defer func() {
+		ctx.Reset()
+		sessionTotpPostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	jkt, ok := a.verifyDpop(req)
+	if !ok || !a.checkCookieWanted(req, ctx.Cookie, jkt) {
+		return
+	}
+
+	login := &ctx.LoginTotp
+	login.Challenge = ctx.Challenge
+	login.Code = ctx.Code
+	login.IpAddr = req.RemoteIP()
+	if err := a.Users.VerifyLoginTotp(login); err != nil {
+		switch err {
+		case domain.ErrValue, domain.ErrCredentials:
+			render.ErrBadReq(req, render.CodeOtpInvalid, "")
+			return
+
+		case domain.ErrKey, domain.ErrExpired:
+			render.ErrGone(req, render.CodeChallengeExpired, "")
+			return
+
+		case domain.ErrThrottled:
+			render.ErrLoginLocked(req, login.RetryAfter)
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	cmd := &ctx.CreateSession
+	cmd.UsedId = login.Result.Id
+	cmd.UserEmail = login.Result.Email
+	cmd.UserRoles = login.Result.Roles
+	cmd.UserProof = login.Result.HasProof
+	cmd.Jkt = jkt
+	cmd.IpAddr = req.RemoteIP()
+	cmd.UserAgent = string(req.UserAgent())
+	if err := a.Sessions.CreateHttp(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	if ctx.Cookie {
+		a.Cookie.write(req, &cmd.Result.Token, cmd.Result.TokenRaw)
+		return
+	}
+	writeSessionToken(req, &cmd.Result.Token, cmd.Result.TokenRaw)
+}
+
+func (a *Adapter) SessionCurrentDelete(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	if err := a.Sessions.Terminate(tok.SessionId); err != nil {
+		render.ErrInternal(req, "")
+		return
+	}
+	a.Cookie.clear(req)
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":"terminated"}`)
+}
+
+// SessionsDelete terminates every other session of the caller,
+// the current one included if the include_current query arg is set.
+func (a *Adapter) SessionsDelete(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	exceptSid := tok.SessionId
+	if req.QueryArgs().GetBool("include_current") {
+		exceptSid = 0
+	}
+
+	n, err := a.Sessions.TerminateAllForUser(tok.User.Id, exceptSid)
+	if err != nil {
+		render.ErrInternal(req, "")
+		return
+	}
+	if exceptSid == 0 {
+		a.Cookie.clear(req)
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":{"terminated":`)
+	_, _ = req.WriteString(strconv.Itoa(n))
+	_, _ = req.WriteString(`}}`)
+}
+
+//easyjson:json
+type SessionsGetRes struct {
+	Sessions   []SessionsGetItem `json:"sessions"`
+	NextCursor int64             `json:"next_cursor,omitempty"`
+}
+
+type SessionsGetItem struct {
+	Id        int64  `json:"id"`
+	IpAddr    string `json:"ip_addr"`
+	UserAgent string `json:"user_agent"`
+	CreatedAt int64  `json:"created_at"`
+	ExpiresAt int64  `json:"expires_at"`
+	Current   bool   `json:"current"`
+}
+
+type SessionsGetCtx struct {
+	ListSessions ports.CommandSessionListActive
+	Res          SessionsGetRes
+}
+
+func (r *SessionsGetCtx) Reset() {
+	r.ListSessions.Reset()
+	r.Res.Sessions = r.Res.Sessions[:0]
+	r.Res.NextCursor = 0
+}
+
+var sessionsGetCtxPool = sync.Pool{
+	New: func() any {
+		// Non-nil Sessions render an empty page as [] instead of null.
+		return &SessionsGetCtx{
+			Res: SessionsGetRes{Sessions: make([]SessionsGetItem, 0, ports.SessionListLimitDefault)},
+		}
+	},
+}
+
+// SessionsGet lists the active sessions of the caller. Pages are
+// requested with the cursor and limit query args.
+func (a *Adapter) SessionsGet(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	ctx := sessionsGetCtxPool.Get().(*SessionsGetCtx)
+	defer func() {
+		ctx.Reset()
+		sessionsGetCtxPool.Put(ctx)
+	}()
+
+	cmd := &ctx.ListSessions
+	cmd.UserId = tok.User.Id
+
+	args := req.QueryArgs()
+	if args.Has("cursor") {
+		cursor, err := args.GetUint("cursor")
+		if err != nil {
+			render.ErrBadReq(req, render.CodeValue, "cursor")
+			return
+		}
+		cmd.Cursor = int64(cursor)
+	}
+	if args.Has("limit") {
+		limit, err := args.GetUint("limit")
+		if err != nil {
+			render.ErrBadReq(req, render.CodeValue, "limit")
+			return
+		}
+		cmd.Limit = limit
+	}
+
+	if err := a.Sessions.ListActive(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	res := &ctx.Res
+	for i := range cmd.Result.Sessions {
+		s := &cmd.Result.Sessions[i]
+		res.Sessions = append(res.Sessions, SessionsGetItem{
+			Id:        s.Id,
+			IpAddr:    s.Http.IpAddr,
+			UserAgent: s.Http.UserAgent,
+			CreatedAt: s.CreatedAt.Unix(),
+			ExpiresAt: s.ExpiresAt.Unix(),
+			Current:   s.Id == tok.SessionId,
+		})
+	}
+	res.NextCursor = cmd.Result.NextCursor
+
+	resData, err := res.MarshalJSON()
+	if err != nil {
+		render.ErrInternal(req, "")
+		return
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":`)
+	_, _ = req.Write(resData)
+	_, _ = req.WriteString(`}`)
+}
+
+// verifyDpop returns the thumbprint of the DPoP proof key to bind the
+// token to, empty if the request has no proof. Invalid proofs are
+// rendered.
+func (a *Adapter) verifyDpop(req *fasthttp.RequestCtx) (string, bool) {
+	jkt, err := a.Dpop.Verify(req, nil)
+	switch err {
+	case nil:
+		return jkt, true
+	case domain.ErrKey:
+		return "", true
+	default:
+		render.ErrDpopProofInvalid(req)
+		return "", false
+	}
+}
+
+// checkCookieWanted renders the error if the client asks for the cookie
+// while cookies are disabled or along with a DPoP proof: a cookie is
+// sent by the browser with no proof.
+func (a *Adapter) checkCookieWanted(req *fasthttp.RequestCtx, wanted bool, jkt string) bool {
+	if wanted && (a.Cookie == nil || len(jkt) > 0) {
+		render.ErrBadReq(req, render.CodeValue, "cookie")
+		return false
+	}
+	return true
+}
+
+// writeSessionToken writes the token response. The encoded token
+// is base64url with a dot, so it needs no JSON escaping.
+func writeSessionToken(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken, tokRaw []byte) {
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":{"token":"`)
+	_, _ = req.Write(tokRaw)
+	_, _ = req.WriteString(`","expires_at":`)
+	_, _ = req.WriteString(strconv.FormatInt(tok.ExpiresAt, 10))
+	if len(tok.Jkt) > 0 {
+		_, _ = req.WriteString(`,"token_type":"DPoP"`)
+	}
+	_, _ = req.WriteString(`}}`)
+}
+
+// writeLoginChallenge asks the client for the second factor.
+func writeLoginChallenge(req *fasthttp.RequestCtx, challenge string, expiresAt int64) {
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":{"challenge":"`)
+	_, _ = req.WriteString(challenge)
+	_, _ = req.WriteString(`","factors":["totp"],"expires_at":`)
+	_, _ = req.WriteString(strconv.FormatInt(expiresAt, 10))
+	_, _ = req.WriteString(`}}`)
+}
@@ -0,0 +1,377 @@
+package http
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/middleware"
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/core/ports"
+	"github.com/boris-army/server/internal/impl/session"
+	"github.com/boris-army/server/internal/impl/user"
+)
+
+func TestSessionPost_Response(t *testing.T) {
+	type tc struct {
+		name          string
+		authErr       error
+		createSessErr error
+		expRes        string
+		expResStatus  int
+	}
+	tcs := []tc{
+		{"bad credentials", domain.ErrCredentials, nil, `{"err":{"code":"CREDENTIALS_INVALID"}}`, fasthttp.StatusUnauthorized},
+		{"value error", domain.ErrValue, nil, `{"err":{"code":"VALUE"}}`, fasthttp.StatusBadRequest},
+		{"auth internal", io.ErrShortWrite, nil, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{"locked", domain.ErrThrottled, nil, `{"err":{"code":"LOGIN_LOCKED"}}`, fasthttp.StatusTooManyRequests},
+		{"second factor", domain.ErrSecondFactor, nil, `{"res":{"challenge":"chal","factors":["totp"],"expires_at":42}}`, fasthttp.StatusOK},
+		{"session value error", nil, domain.ErrValue, `{"err":{"code":"VALUE"}}`, fasthttp.StatusBadRequest},
+		{"session internal", nil, io.ErrShortWrite, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{"ok", nil, nil, `{"res":{"token":"tok","expires_at":42}}`, fasthttp.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userDriver := user.NewMockDriverUser(ctrl)
+			sessDriver := session.NewMockDriverSession(ctrl)
+			a := Adapter{Users: userDriver, Sessions: sessDriver}
+
+			const reqBody = `{"email": "pgarin@old.me", "password": "qwerty123"}`
+			userDriver.EXPECT().Authenticate(&ports.CommandUserAuthenticate{
+				Email:    "pgarin@old.me",
+				Password: "qwerty123",
+				IpAddr:   net.IPv4zero,
+			}).DoAndReturn(func(cmd *ports.CommandUserAuthenticate) error {
+				cmd.Result.Id = 1
+				cmd.Result.Roles = domain.UserRoleAdmin
+				cmd.Result.HasProof = domain.UserProofEmail
+				cmd.RetryAfter = time.Millisecond * 1500
+				cmd.Result.Email = cmd.Email
+				cmd.Challenge.Token = "chal"
+				cmd.Challenge.ExpiresAt = time.Unix(42, 0)
+				return tc.authErr
+			})
+			if tc.authErr == nil {
+				sessDriver.EXPECT().CreateHttp(gomock.Any()).
+					DoAndReturn(func(cmd *ports.CommandSessionHttpCreate) error {
+						assert.Equal(t, int64(1), cmd.UsedId)
+						assert.Equal(t, "pgarin@old.me", cmd.UserEmail)
+						assert.Equal(t, domain.UserRoleAdmin, cmd.UserRoles)
+						assert.Equal(t, domain.UserProofEmail, cmd.UserProof)
+						assert.Equal(t, "HTTPie", cmd.UserAgent)
+						cmd.Result.TokenRaw = append(cmd.Result.TokenRaw, "tok"...)
+						cmd.Result.Token.ExpiresAt = 42
+						return tc.createSessErr
+					})
+			}
+
+			req := &fasthttp.RequestCtx{}
+			req.Request.Header.SetUserAgent("HTTPie")
+			req.Request.SetBody([]byte(reqBody))
+			a.SessionPost(req)
+
+			assert.Equal(t, tc.expResStatus, req.Response.StatusCode())
+			assert.Equal(t, tc.expRes, string(req.Response.Body()))
+			assert.Equal(t, "application/json", string(req.Response.Header.ContentType()))
+			if tc.authErr == domain.ErrThrottled {
+				assert.Equal(t, "2", string(req.Response.Header.Peek("Retry-After")))
+			}
+		})
+	}
+}
+
+func TestSessionPost_DpopProofInvalid(t *testing.T) {
+	a := Adapter{Dpop: middleware.NewDpop("", time.Minute)}
+
+	req := &fasthttp.RequestCtx{}
+	req.Request.Header.Set("DPoP", "foo")
+	req.Request.SetBody([]byte(`{"email": "pgarin@old.me", "password": "qwerty123"}`))
+	a.SessionPost(req)
+
+	assert.Equal(t, fasthttp.StatusUnauthorized, req.Response.StatusCode())
+	assert.Equal(t, `{"err":{"code":"DPOP_PROOF_INVALID"}}`, string(req.Response.Body()))
+}
+
+func TestWriteSessionToken_Dpop(t *testing.T) {
+	req := &fasthttp.RequestCtx{}
+	writeSessionToken(req, &domain.SessionHttpToken{ExpiresAt: 42, Jkt: "jkt"}, []byte("tok"))
+	assert.Equal(t, `{"res":{"token":"tok","expires_at":42,"token_type":"DPoP"}}`, string(req.Response.Body()))
+}
+
+func TestSessionPost_Cookie(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userDriver := user.NewMockDriverUser(ctrl)
+	sessDriver := session.NewMockDriverSession(ctrl)
+	csrf := middleware.NewCsrf("0123456789abcdef0123456789abcdef", nil)
+	a := Adapter{
+		Users:    userDriver,
+		Sessions: sessDriver,
+		Cookie: &SessionCookie{
+			Domain:   "boris.army",
+			Secure:   true,
+			SameSite: fasthttp.CookieSameSiteStrictMode,
+			Csrf:     csrf,
+		},
+	}
+
+	userDriver.EXPECT().Authenticate(gomock.Any()).Return(nil)
+	sessDriver.EXPECT().CreateHttp(gomock.Any()).
+		DoAndReturn(func(cmd *ports.CommandSessionHttpCreate) error {
+			cmd.Result.TokenRaw = append(cmd.Result.TokenRaw, "tok"...)
+			cmd.Result.Token.SessionId = 7
+			cmd.Result.Token.ExpiresAt = 42
+			return nil
+		})
+
+	req := &fasthttp.RequestCtx{}
+	req.Request.SetBody([]byte(`{"email": "pgarin@old.me", "password": "qwerty123", "cookie": true}`))
+	a.SessionPost(req)
+
+	assert.Equal(t, fasthttp.StatusOK, req.Response.StatusCode())
+	assert.Equal(t, `{"res":{"expires_at":42,"csrf_token":"`+csrf.Token(7)+`"}}`, string(req.Response.Body()))
+
+	var cookie fasthttp.Cookie
+	cookie.SetKey("access_token")
+	assert.True(t, req.Response.Header.Cookie(&cookie))
+	assert.Equal(t, "tok", string(cookie.Value()))
+	assert.True(t, cookie.HTTPOnly())
+	assert.True(t, cookie.Secure())
+	assert.Equal(t, fasthttp.CookieSameSiteStrictMode, cookie.SameSite())
+	assert.Equal(t, "boris.army", string(cookie.Domain()))
+	assert.Equal(t, "/", string(cookie.Path()))
+	assert.Equal(t, int64(42), cookie.Expire().Unix())
+
+	cookie.Reset()
+	cookie.SetKey("csrf_token")
+	assert.True(t, req.Response.Header.Cookie(&cookie))
+	assert.Equal(t, csrf.Token(7), string(cookie.Value()))
+	assert.False(t, cookie.HTTPOnly())
+}
+
+func TestSessionPost_CookieUnwanted(t *testing.T) {
+	const reqBody = `{"email": "pgarin@old.me", "password": "qwerty123", "cookie": true}`
+
+	// Disabled cookies.
+	req := &fasthttp.RequestCtx{}
+	req.Request.SetBody([]byte(reqBody))
+	(&Adapter{}).SessionPost(req)
+	assert.Equal(t, fasthttp.StatusBadRequest, req.Response.StatusCode())
+	assert.Equal(t, `{"err":{"code":"VALUE","mes":"cookie"}}`, string(req.Response.Body()))
+
+	// DPoP bound token.
+	req = &fasthttp.RequestCtx{}
+	assert.False(t, (&Adapter{Cookie: &SessionCookie{}}).checkCookieWanted(req, true, "jkt"))
+	assert.Equal(t, fasthttp.StatusBadRequest, req.Response.StatusCode())
+}
+
+func TestSessionTotpPost_Response(t *testing.T) {
+	type tc struct {
+		name         string
+		verifyErr    error
+		expRes       string
+		expResStatus int
+	}
+	tcs := []tc{
+		{"wrong code", domain.ErrCredentials, `{"err":{"code":"OTP_INVALID"}}`, fasthttp.StatusBadRequest},
+		{"unknown challenge", domain.ErrKey, `{"err":{"code":"LOGIN_CHALLENGE_EXPIRED"}}`, fasthttp.StatusGone},
+		{"expired challenge", domain.ErrExpired, `{"err":{"code":"LOGIN_CHALLENGE_EXPIRED"}}`, fasthttp.StatusGone},
+		{"locked", domain.ErrThrottled, `{"err":{"code":"LOGIN_LOCKED"}}`, fasthttp.StatusTooManyRequests},
+		{"internal", io.ErrShortWrite, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{"ok", nil, `{"res":{"token":"tok","expires_at":42}}`, fasthttp.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userDriver := user.NewMockDriverUser(ctrl)
+			sessDriver := session.NewMockDriverSession(ctrl)
+			a := Adapter{Users: userDriver, Sessions: sessDriver}
+
+			userDriver.EXPECT().VerifyLoginTotp(&ports.CommandUserLoginTotp{
+				Challenge: "chal",
+				Code:      "123456",
+				IpAddr:    net.IPv4zero,
+			}).DoAndReturn(func(cmd *ports.CommandUserLoginTotp) error {
+				cmd.Result.Id = 1
+				cmd.Result.Email = "pgarin@old.me"
+				cmd.RetryAfter = time.Millisecond * 1500
+				return tc.verifyErr
+			})
+			if tc.verifyErr == nil {
+				sessDriver.EXPECT().CreateHttp(gomock.Any()).
+					DoAndReturn(func(cmd *ports.CommandSessionHttpCreate) error {
+						assert.Equal(t, int64(1), cmd.UsedId)
+						assert.Equal(t, "pgarin@old.me", cmd.UserEmail)
+						cmd.Result.TokenRaw = append(cmd.Result.TokenRaw, "tok"...)
+						cmd.Result.Token.ExpiresAt = 42
+						return nil
+					})
+			}
+
+			req := &fasthttp.RequestCtx{}
+			req.Request.SetBody([]byte(`{"challenge": "chal", "code": "123456"}`))
+			a.SessionTotpPost(req)
+
+			assert.Equal(t, tc.expResStatus, req.Response.StatusCode())
+			assert.Equal(t, tc.expRes, string(req.Response.Body()))
+			if tc.verifyErr == domain.ErrThrottled {
+				assert.Equal(t, "2", string(req.Response.Header.Peek("Retry-After")))
+			}
+		})
+	}
+}
+
+func TestSessionCurrentDelete_Response(t *testing.T) {
+	type tc struct {
+		name         string
+		driverErr    error
+		expRes       string
+		expResStatus int
+	}
+	tcs := []tc{
+		{"internal error", io.ErrShortWrite, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{"ok", nil, `{"res":"terminated"}`, fasthttp.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sessDriver := session.NewMockDriverSession(ctrl)
+			a := Adapter{Sessions: sessDriver, Cookie: &SessionCookie{}}
+
+			sessDriver.EXPECT().Terminate(int64(7)).Return(tc.driverErr)
+
+			req := &fasthttp.RequestCtx{}
+			a.SessionCurrentDelete(req, &domain.SessionHttpToken{SessionId: 7})
+
+			assert.Equal(t, tc.expResStatus, req.Response.StatusCode())
+			assert.Equal(t, tc.expRes, string(req.Response.Body()))
+
+			var cookie fasthttp.Cookie
+			cookie.SetKey("access_token")
+			assert.Equal(t, tc.driverErr == nil, req.Response.Header.Cookie(&cookie), "cookie cleared")
+		})
+	}
+}
+
+func TestSessionsDelete_Response(t *testing.T) {
+	type tc struct {
+		name         string
+		uri          string
+		expExceptSid int64
+		driverErr    error
+		expRes       string
+		expResStatus int
+	}
+	tcs := []tc{
+		{"internal error", "/sessions", 7, io.ErrShortWrite, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{"keep current", "/sessions", 7, nil, `{"res":{"terminated":2}}`, fasthttp.StatusOK},
+		{"include current", "/sessions?include_current=1", 0, nil, `{"res":{"terminated":2}}`, fasthttp.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sessDriver := session.NewMockDriverSession(ctrl)
+			a := Adapter{Sessions: sessDriver}
+
+			sessDriver.EXPECT().TerminateAllForUser(int64(1), tc.expExceptSid).Return(2, tc.driverErr)
+
+			req := &fasthttp.RequestCtx{}
+			req.Request.SetRequestURI(tc.uri)
+			a.SessionsDelete(req, &domain.SessionHttpToken{
+				SessionId: 7,
+				User:      domain.SessionHttpTokenUser{Id: 1},
+			})
+
+			assert.Equal(t, tc.expResStatus, req.Response.StatusCode())
+			assert.Equal(t, tc.expRes, string(req.Response.Body()))
+		})
+	}
+}
+
+func TestSessionsGet_Response(t *testing.T) {
+	type tc struct {
+		name         string
+		uri          string
+		expCmd       bool
+		driverErr    error
+		expRes       string
+		expResStatus int
+	}
+	tcs := []tc{
+		{"bad cursor", "/sessions?cursor=x", false, nil, `{"err":{"code":"VALUE","mes":"cursor"}}`, fasthttp.StatusBadRequest},
+		{"value error", "/sessions?limit=1000", true, domain.ErrValue, `{"err":{"code":"VALUE"}}`, fasthttp.StatusBadRequest},
+		{"internal error", "/sessions", true, io.ErrShortWrite, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{
+			"ok", "/sessions?cursor=10&limit=2", true, nil,
+			`{"res":{"sessions":[` +
+				`{"id":7,"ip_addr":"127.0.0.1","user_agent":"HTTPie \"1\"","created_at":1,"expires_at":2,"current":true},` +
+				`{"id":5,"ip_addr":"127.0.0.1","user_agent":"curl","created_at":1,"expires_at":2,"current":false}` +
+				`],"next_cursor":5}}`,
+			fasthttp.StatusOK,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sessDriver := session.NewMockDriverSession(ctrl)
+			a := Adapter{Sessions: sessDriver}
+
+			if tc.expCmd {
+				sessDriver.EXPECT().ListActive(gomock.Any()).
+					DoAndReturn(func(cmd *ports.CommandSessionListActive) error {
+						assert.Equal(t, int64(1), cmd.UserId)
+						if tc.driverErr != nil {
+							return tc.driverErr
+						}
+						assert.Equal(t, int64(10), cmd.Cursor)
+						assert.Equal(t, 2, cmd.Limit)
+						for _, s := range []struct {
+							id int64
+							ua string
+						}{{7, `HTTPie "1"`}, {5, "curl"}} {
+							cmd.Result.Sessions = append(cmd.Result.Sessions, domain.Session{
+								Id:        s.id,
+								CreatedAt: time.Unix(1, 0),
+								ExpiresAt: time.Unix(2, 0),
+								Http:      domain.SessionHttp{IpAddr: "127.0.0.1", UserAgent: s.ua},
+							})
+						}
+						cmd.Result.NextCursor = 5
+						return nil
+					})
+			}
+
+			req := &fasthttp.RequestCtx{}
+			req.Request.SetRequestURI(tc.uri)
+			a.SessionsGet(req, &domain.SessionHttpToken{
+				SessionId: 7,
+				User:      domain.SessionHttpTokenUser{Id: 1},
+			})
+
+			assert.Equal(t, tc.expResStatus, req.Response.StatusCode())
+			assert.Equal(t, tc.expRes, string(req.Response.Body()))
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package http
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/adapters/http/render"
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/core/ports"
+)
+
+//go:generate easyjson $GOFILE
+
+//easyjson:json
+type UserTotpPostRes struct {
+	Secret        string   `json:"secret"`
+	Uri           string   `json:"uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type UserTotpPostCtx struct {
+	Enroll ports.CommandUserTotpEnroll
+	Res    UserTotpPostRes
+}
+
+func (r *UserTotpPostCtx) Reset() {
+	r.Enroll.Reset()
+	r.Res.Secret = ""
+	r.Res.Uri = ""
+	r.Res.RecoveryCodes = nil
+}
+
+var userTotpPostCtxPool = sync.Pool{
+	New: func() any {
+		return &UserTotpPostCtx{}
+	},
+}
+
+// UserTotpPost starts the caller's 2FA enrollment. The secret is
+// returned once, 2FA is enabled by UserTotpConfirmPost.
+func (a *Adapter) UserTotpPost(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	ctx := userTotpPostCtxPool.Get().(*UserTotpPostCtx)
+	defer func() {
+		ctx.Reset()
+		userTotpPostCtxPool.Put(ctx)
+	}()
+
+	cmd := &ctx.Enroll
+	cmd.UserId = tok.User.Id
+	if err := a.Users.EnrollTotp(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		case domain.ErrExists:
+			render.ErrConflict(req, render.CodeTotpEnabled, "")
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	res := &ctx.Res
+	res.Secret = cmd.Result.Secret
+	res.Uri = cmd.Result.Uri
+	res.RecoveryCodes = cmd.Result.RecoveryCodes
+
+	resData, err := res.MarshalJSON()
+	if err != nil {
+		render.ErrInternal(req, "")
+		return
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":`)
+	_, _ = req.Write(resData)
+	_, _ = req.WriteString(`}`)
+}
+
+//easyjson:json
+type UserTotpConfirmPostCtx struct {
+	Code    string                       `json:"code,nocopy"`
+	Confirm ports.CommandUserTotpConfirm `json:"-"`
+}
+
+func (r *UserTotpConfirmPostCtx) Reset() {
+	r.Code = ""
+	r.Confirm.Reset()
+}
+
+var userTotpConfirmPostCtxPool = sync.Pool{
+	New: func() any {
+		return &UserTotpConfirmPostCtx{}
+	},
+}
+
+func (a *Adapter) UserTotpConfirmPost(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	ctx := userTotpConfirmPostCtxPool.Get().(*UserTotpConfirmPostCtx)
+	defer func() {
+		ctx.Reset()
+		userTotpConfirmPostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	cmd := &ctx.Confirm
+	cmd.UserId = tok.User.Id
+	cmd.Code = ctx.Code
+	if err := a.Users.ConfirmTotp(cmd); err != nil {
+		switch err {
+		case domain.ErrValue, domain.ErrCredentials:
+			render.ErrBadReq(req, render.CodeOtpInvalid, "")
+			return
+
+		case domain.ErrKey:
+			render.ErrConflict(req, render.CodeTotpNotEnrolled, "")
+			return
+
+		case domain.ErrExists:
+			render.ErrConflict(req, render.CodeTotpEnabled, "")
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":"enabled"}`)
+}
+
+//easyjson:json
+type UserTotpDeleteCtx struct {
+	Password string                       `json:"password,nocopy"`
+	Code     string                       `json:"code,nocopy"`
+	Disable  ports.CommandUserTotpDisable `json:"-"`
+}
+
+func (r *UserTotpDeleteCtx) Reset() {
+	r.Password = ""
+	r.Code = ""
+	r.Disable.Reset()
+}
+
+var userTotpDeleteCtxPool = sync.Pool{
+	New: func() any {
+		return &UserTotpDeleteCtx{}
+	},
+}
+
+// UserTotpDelete disables the caller's 2FA. Both the password and
+// a current code are required.
+func (a *Adapter) UserTotpDelete(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	ctx := userTotpDeleteCtxPool.Get().(*UserTotpDeleteCtx)
+	defer func() {
+		ctx.Reset()
+		userTotpDeleteCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	cmd := &ctx.Disable
+	cmd.UserId = tok.User.Id
+	cmd.Password = ctx.Password
+	cmd.Code = ctx.Code
+	if err := a.Users.DisableTotp(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		case domain.ErrCredentials:
+			render.ErrBadReq(req, render.CodeCredentialsInvalid, "")
+			return
+
+		case domain.ErrKey:
+			render.ErrConflict(req, render.CodeTotpNotEnrolled, "")
+			return
+
+		case domain.ErrOverloaded:
+			render.ErrOverloaded(req, a.OverloadRetryAfter)
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":"disabled"}`)
+}
+
+//easyjson:json
+type UserRecoveryCodesPostRes struct {
+	Codes []string `json:"codes"`
+}
+
+//easyjson:json
+type UserRecoveryCodesPostCtx struct {
+	Password   string                                   `json:"password,nocopy"`
+	Code       string                                   `json:"code,nocopy"`
+	Regenerate ports.CommandUserRecoveryCodesRegenerate `json:"-"`
+	Res        UserRecoveryCodesPostRes                 `json:"-"`
+}
+
+func (r *UserRecoveryCodesPostCtx) Reset() {
+	r.Password = ""
+	r.Code = ""
+	r.Regenerate.Reset()
+	r.Res.Codes = nil
+}
+
+var userRecoveryCodesPostCtxPool = sync.Pool{
+	New: func() any {
+		return &UserRecoveryCodesPostCtx{}
+	},
+}
+
+// UserRecoveryCodesPost replaces the caller's recovery codes with
+// a new set, returned once. Both the password and a current code
+// are required.
+func (a *Adapter) UserRecoveryCodesPost(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	ctx := userRecoveryCodesPostCtxPool.Get().(*UserRecoveryCodesPostCtx)
+	defer func() {
+		ctx.Reset()
+		userRecoveryCodesPostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	cmd := &ctx.Regenerate
+	cmd.UserId = tok.User.Id
+	cmd.Password = ctx.Password
+	cmd.Code = ctx.Code
+	if err := a.Users.RegenerateRecoveryCodes(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		case domain.ErrCredentials:
+			render.ErrBadReq(req, render.CodeCredentialsInvalid, "")
+			return
+
+		case domain.ErrKey:
+			render.ErrConflict(req, render.CodeTotpNotEnrolled, "")
+			return
+
+		case domain.ErrOverloaded:
+			render.ErrOverloaded(req, a.OverloadRetryAfter)
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	ctx.Res.Codes = cmd.Result.Codes
+	resData, err := ctx.Res.MarshalJSON()
+	if err != nil {
+		render.ErrInternal(req, "")
+		return
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":`)
+	_, _ = req.Write(resData)
+	_, _ = req.WriteString(`}`)
+}
+
+// UserRecoveryCodesGet counts the caller's unused recovery codes.
+func (a *Adapter) UserRecoveryCodesGet(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	cmd := ports.CommandUserRecoveryCodesCount{UserId: tok.User.Id}
+	if err := a.Users.CountRecoveryCodes(&cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":{"remaining":`)
+	_, _ = req.WriteString(strconv.Itoa(cmd.Result.Remaining))
+	_, _ = req.WriteString(`}}`)
+}
@@ -0,0 +1,441 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package http
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp(in *jlexer.Lexer, out *UserTotpPostRes) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "secret":
+			out.Secret = string(in.String())
+		case "uri":
+			out.Uri = string(in.String())
+		case "recovery_codes":
+			if in.IsNull() {
+				in.Skip()
+				out.RecoveryCodes = nil
+			} else {
+				in.Delim('[')
+				if out.RecoveryCodes == nil {
+					if !in.IsDelim(']') {
+						out.RecoveryCodes = make([]string, 0, 4)
+					} else {
+						out.RecoveryCodes = []string{}
+					}
+				} else {
+					out.RecoveryCodes = (out.RecoveryCodes)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 string
+					v1 = string(in.String())
+					out.RecoveryCodes = append(out.RecoveryCodes, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp(out *jwriter.Writer, in UserTotpPostRes) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"secret\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Secret))
+	}
+	{
+		const prefix string = ",\"uri\":"
+		out.RawString(prefix)
+		out.String(string(in.Uri))
+	}
+	{
+		const prefix string = ",\"recovery_codes\":"
+		out.RawString(prefix)
+		if in.RecoveryCodes == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.RecoveryCodes {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v3))
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v UserTotpPostRes) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v UserTotpPostRes) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *UserTotpPostRes) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *UserTotpPostRes) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp(l, v)
+}
+func easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp1(in *jlexer.Lexer, out *UserTotpDeleteCtx) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "password":
+			out.Password = string(in.UnsafeString())
+		case "code":
+			out.Code = string(in.UnsafeString())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp1(out *jwriter.Writer, in UserTotpDeleteCtx) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"password\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Password))
+	}
+	{
+		const prefix string = ",\"code\":"
+		out.RawString(prefix)
+		out.String(string(in.Code))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v UserTotpDeleteCtx) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v UserTotpDeleteCtx) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *UserTotpDeleteCtx) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *UserTotpDeleteCtx) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp1(l, v)
+}
+func easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp2(in *jlexer.Lexer, out *UserTotpConfirmPostCtx) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "code":
+			out.Code = string(in.UnsafeString())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp2(out *jwriter.Writer, in UserTotpConfirmPostCtx) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"code\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Code))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v UserTotpConfirmPostCtx) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp2(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v UserTotpConfirmPostCtx) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp2(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *UserTotpConfirmPostCtx) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp2(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *UserTotpConfirmPostCtx) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp2(l, v)
+}
+func easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp3(in *jlexer.Lexer, out *UserRecoveryCodesPostRes) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "codes":
+			if in.IsNull() {
+				in.Skip()
+				out.Codes = nil
+			} else {
+				in.Delim('[')
+				if out.Codes == nil {
+					if !in.IsDelim(']') {
+						out.Codes = make([]string, 0, 4)
+					} else {
+						out.Codes = []string{}
+					}
+				} else {
+					out.Codes = (out.Codes)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v4 string
+					v4 = string(in.String())
+					out.Codes = append(out.Codes, v4)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp3(out *jwriter.Writer, in UserRecoveryCodesPostRes) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"codes\":"
+		out.RawString(prefix[1:])
+		if in.Codes == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v5, v6 := range in.Codes {
+				if v5 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v6))
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v UserRecoveryCodesPostRes) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp3(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v UserRecoveryCodesPostRes) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp3(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *UserRecoveryCodesPostRes) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp3(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *UserRecoveryCodesPostRes) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp3(l, v)
+}
+func easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp4(in *jlexer.Lexer, out *UserRecoveryCodesPostCtx) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "password":
+			out.Password = string(in.UnsafeString())
+		case "code":
+			out.Code = string(in.UnsafeString())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp4(out *jwriter.Writer, in UserRecoveryCodesPostCtx) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"password\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Password))
+	}
+	{
+		const prefix string = ",\"code\":"
+		out.RawString(prefix)
+		out.String(string(in.Code))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v UserRecoveryCodesPostCtx) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp4(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v UserRecoveryCodesPostCtx) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonFdbc1befEncodeGithubComBorisArmyServerInternalAdaptersHttp4(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *UserRecoveryCodesPostCtx) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp4(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *UserRecoveryCodesPostCtx) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonFdbc1befDecodeGithubComBorisArmyServerInternalAdaptersHttp4(l, v)
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"io"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/core/ports"
+	"github.com/boris-army/server/internal/impl/user"
+)
+
+func TestUserRecoveryCodesPost_Response(t *testing.T) {
+	type tc struct {
+		name         string
+		driverErr    error
+		expRes       string
+		expResStatus int
+	}
+	tcs := []tc{
+		{"wrong credentials", domain.ErrCredentials, `{"err":{"code":"CREDENTIALS_INVALID"}}`, fasthttp.StatusBadRequest},
+		{"2fa disabled", domain.ErrKey, `{"err":{"code":"TOTP_NOT_ENROLLED"}}`, fasthttp.StatusConflict},
+		{"overloaded", domain.ErrOverloaded, `{"err":{"code":"OVERLOADED"}}`, fasthttp.StatusServiceUnavailable},
+		{"internal error", io.ErrShortWrite, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{"ok", nil, `{"res":{"codes":["abcde-fghij-klmn2"]}}`, fasthttp.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userDriver := user.NewMockDriverUser(ctrl)
+			a := Adapter{Users: userDriver}
+
+			userDriver.EXPECT().RegenerateRecoveryCodes(gomock.Any()).
+				DoAndReturn(func(cmd *ports.CommandUserRecoveryCodesRegenerate) error {
+					assert.Equal(t, int64(1), cmd.UserId)
+					assert.Equal(t, "qwerty123", cmd.Password)
+					assert.Equal(t, "123456", cmd.Code)
+					cmd.Result.Codes = append(cmd.Result.Codes, "abcde-fghij-klmn2")
+					return tc.driverErr
+				})
+
+			req := &fasthttp.RequestCtx{}
+			req.Request.SetBody([]byte(`{"password": "qwerty123", "code": "123456"}`))
+			a.UserRecoveryCodesPost(req, &domain.SessionHttpToken{User: domain.SessionHttpTokenUser{Id: 1}})
+
+			assert.Equal(t, tc.expResStatus, req.Response.StatusCode())
+			assert.Equal(t, tc.expRes, string(req.Response.Body()))
+		})
+	}
+}
+
+func TestUserRecoveryCodesGet_Response(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userDriver := user.NewMockDriverUser(ctrl)
+	a := Adapter{Users: userDriver}
+
+	userDriver.EXPECT().CountRecoveryCodes(&ports.CommandUserRecoveryCodesCount{UserId: 1}).
+		DoAndReturn(func(cmd *ports.CommandUserRecoveryCodesCount) error {
+			cmd.Result.Remaining = 7
+			return nil
+		})
+
+	req := &fasthttp.RequestCtx{}
+	a.UserRecoveryCodesGet(req, &domain.SessionHttpToken{User: domain.SessionHttpTokenUser{Id: 1}})
+
+	assert.Equal(t, fasthttp.StatusOK, req.Response.StatusCode())
+	assert.Equal(t, `{"res":{"remaining":7}}`, string(req.Response.Body()))
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"strconv"
 	"sync"
 
 	"github.com/valyala/fasthttp"
@@ -61,6 +62,10 @@ func (a *Adapter) UserPost(req *fasthttp.RequestCtx) {
 			render.ErrConflict(req, render.CodeUserExists, "")
 			return
 
+		case domain.ErrOverloaded:
+			render.ErrOverloaded(req, a.OverloadRetryAfter)
+			return
+
 		default:
 			render.ErrInternal(req, "")
 			return
@@ -70,3 +75,391 @@ func (a *Adapter) UserPost(req *fasthttp.RequestCtx) {
 	req.SetContentType("application/json")
 	_, _ = req.WriteString(`{"res":"24h email confirmation"}`)
 }
+
+//easyjson:json
+type UserEmailConfirmationPostCtx struct {
+	Email  string                                   `json:"email,nocopy"`
+	Resend ports.CommandUserEmailConfirmationResend `json:"-"`
+}
+
+func (r *UserEmailConfirmationPostCtx) Reset() {
+	r.Email = ""
+	r.Resend.Reset()
+}
+
+var userEmailConfirmationPostCtxPool = sync.Pool{
+	New: func() any {
+		return &UserEmailConfirmationPostCtx{}
+	},
+}
+
+// UserEmailConfirmationPost resends the confirmation email.
+func (a *Adapter) UserEmailConfirmationPost(req *fasthttp.RequestCtx) {
+	ctx := userEmailConfirmationPostCtxPool.Get().(*UserEmailConfirmationPostCtx)
+	defer func() {
+		ctx.Reset()
+		userEmailConfirmationPostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	cmd := &ctx.Resend
+	cmd.Email = ctx.Email
+	if err := a.Users.ResendEmailConfirmation(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":"sent"}`)
+}
+
+//easyjson:json
+type UserEmailConfirmationConfirmPostCtx struct {
+	Token   string                        `json:"token,nocopy"`
+	Confirm ports.CommandUserEmailConfirm `json:"-"`
+}
+
+func (r *UserEmailConfirmationConfirmPostCtx) Reset() {
+	r.Token = ""
+	r.Confirm.Reset()
+}
+
+var userEmailConfirmationConfirmPostCtxPool = sync.Pool{
+	New: func() any {
+		return &UserEmailConfirmationConfirmPostCtx{}
+	},
+}
+
+func (a *Adapter) UserEmailConfirmationConfirmPost(req *fasthttp.RequestCtx) {
+	ctx := userEmailConfirmationConfirmPostCtxPool.Get().(*UserEmailConfirmationConfirmPostCtx)
+	defer func() {
+		ctx.Reset()
+		userEmailConfirmationConfirmPostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	cmd := &ctx.Confirm
+	cmd.Token = ctx.Token
+	if err := a.Users.ConfirmEmail(cmd); err != nil {
+		switch err {
+		case domain.ErrValue, domain.ErrKey:
+			render.ErrBadReq(req, render.CodeConfirmInvalid, "")
+			return
+
+		case domain.ErrExpired:
+			render.ErrGone(req, render.CodeConfirmExpired, "")
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":"confirmed"}`)
+}
+
+//easyjson:json
+type UserPhonePostCtx struct {
+	Phone  string                       `json:"phone,nocopy"`
+	Attach ports.CommandUserPhoneAttach `json:"-"`
+}
+
+func (r *UserPhonePostCtx) Reset() {
+	r.Phone = ""
+	r.Attach.Reset()
+}
+
+var userPhonePostCtxPool = sync.Pool{
+	New: func() any {
+		return &UserPhonePostCtx{}
+	},
+}
+
+// UserPhonePost sends a verification code to the caller's new phone.
+func (a *Adapter) UserPhonePost(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	ctx := userPhonePostCtxPool.Get().(*UserPhonePostCtx)
+	defer func() {
+		ctx.Reset()
+		userPhonePostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	cmd := &ctx.Attach
+	cmd.UserId = tok.User.Id
+	cmd.Phone = ctx.Phone
+	if err := a.Users.AttachPhone(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		case domain.ErrThrottled:
+			render.ErrThrottled(req, cmd.Result.RetryAfter)
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":"sent"}`)
+}
+
+//easyjson:json
+type UserPhoneVerifyPostCtx struct {
+	Code   string                       `json:"code,nocopy"`
+	Verify ports.CommandUserPhoneVerify `json:"-"`
+}
+
+func (r *UserPhoneVerifyPostCtx) Reset() {
+	r.Code = ""
+	r.Verify.Reset()
+}
+
+var userPhoneVerifyPostCtxPool = sync.Pool{
+	New: func() any {
+		return &UserPhoneVerifyPostCtx{}
+	},
+}
+
+func (a *Adapter) UserPhoneVerifyPost(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	ctx := userPhoneVerifyPostCtxPool.Get().(*UserPhoneVerifyPostCtx)
+	defer func() {
+		ctx.Reset()
+		userPhoneVerifyPostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	cmd := &ctx.Verify
+	cmd.UserId = tok.User.Id
+	cmd.Code = ctx.Code
+	if err := a.Users.VerifyPhone(cmd); err != nil {
+		switch err {
+		case domain.ErrValue, domain.ErrCredentials:
+			render.ErrBadReq(req, render.CodeOtpInvalid, "")
+			return
+
+		case domain.ErrKey, domain.ErrExpired:
+			render.ErrGone(req, render.CodeOtpExpired, "")
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":"verified"}`)
+}
+
+//easyjson:json
+type UserPasswordResetPostCtx struct {
+	Email   string                                `json:"email,nocopy"`
+	Request ports.CommandUserPasswordResetRequest `json:"-"`
+}
+
+func (r *UserPasswordResetPostCtx) Reset() {
+	r.Email = ""
+	r.Request.Reset()
+}
+
+var userPasswordResetPostCtxPool = sync.Pool{
+	New: func() any {
+		return &UserPasswordResetPostCtx{}
+	},
+}
+
+// UserPasswordResetPost sends a password reset link. The response
+// is the same whether the email is registered or not.
+func (a *Adapter) UserPasswordResetPost(req *fasthttp.RequestCtx) {
+	ctx := userPasswordResetPostCtxPool.Get().(*UserPasswordResetPostCtx)
+	defer func() {
+		ctx.Reset()
+		userPasswordResetPostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	cmd := &ctx.Request
+	cmd.Email = ctx.Email
+	if err := a.Users.RequestPasswordReset(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":"sent"}`)
+}
+
+//easyjson:json
+type UserPasswordResetConfirmPostCtx struct {
+	Token         string                         `json:"token,nocopy"`
+	Password      string                         `json:"password,nocopy"`
+	ResetPassword ports.CommandUserPasswordReset `json:"-"`
+}
+
+func (r *UserPasswordResetConfirmPostCtx) Reset() {
+	r.Token = ""
+	r.Password = ""
+	r.ResetPassword.Reset()
+}
+
+var userPasswordResetConfirmPostCtxPool = sync.Pool{
+	New: func() any {
+		return &UserPasswordResetConfirmPostCtx{}
+	},
+}
+
+func (a *Adapter) UserPasswordResetConfirmPost(req *fasthttp.RequestCtx) {
+	ctx := userPasswordResetConfirmPostCtxPool.Get().(*UserPasswordResetConfirmPostCtx)
+	defer func() {
+		ctx.Reset()
+		userPasswordResetConfirmPostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	cmd := &ctx.ResetPassword
+	cmd.Token = ctx.Token
+	cmd.Password = ctx.Password
+	if err := a.Users.ResetPassword(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		case domain.ErrKey:
+			render.ErrBadReq(req, render.CodeResetInvalid, "")
+			return
+
+		case domain.ErrExpired:
+			render.ErrGone(req, render.CodeResetExpired, "")
+			return
+
+		case domain.ErrOverloaded:
+			render.ErrOverloaded(req, a.OverloadRetryAfter)
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":"reset"}`)
+}
+
+//easyjson:json
+type UserPasswordPostCtx struct {
+	CurrentPassword string                          `json:"current_password,nocopy"`
+	NewPassword     string                          `json:"new_password,nocopy"`
+	TerminateOthers bool                            `json:"terminate_other_sessions"`
+	ChangePassword  ports.CommandUserPasswordChange `json:"-"`
+}
+
+func (r *UserPasswordPostCtx) Reset() {
+	r.CurrentPassword = ""
+	r.NewPassword = ""
+	r.TerminateOthers = false
+	r.ChangePassword.Reset()
+}
+
+var userPasswordPostCtxPool = sync.Pool{
+	New: func() any {
+		return &UserPasswordPostCtx{}
+	},
+}
+
+// UserPasswordPost changes the caller's password, terminating their
+// other sessions if asked to.
+func (a *Adapter) UserPasswordPost(req *fasthttp.RequestCtx, tok *domain.SessionHttpToken) {
+	ctx := userPasswordPostCtxPool.Get().(*UserPasswordPostCtx)
+	defer func() {
+		ctx.Reset()
+		userPasswordPostCtxPool.Put(ctx)
+	}()
+
+	if err := ctx.UnmarshalJSON(req.PostBody()); err != nil {
+		render.ErrBadReq(req, render.CodeValue, "")
+		return
+	}
+
+	cmd := &ctx.ChangePassword
+	cmd.UserId = tok.User.Id
+	cmd.SessionId = tok.SessionId
+	cmd.CurrentPassword = ctx.CurrentPassword
+	cmd.NewPassword = ctx.NewPassword
+	cmd.TerminateOthers = ctx.TerminateOthers
+	if err := a.Users.ChangePassword(cmd); err != nil {
+		switch err {
+		case domain.ErrValue:
+			render.ErrBadReq(req, render.CodeValue, "")
+			return
+
+		case domain.ErrCredentials:
+			// Not a 401, the access token itself is fine.
+			render.ErrBadReq(req, render.CodeCredentialsInvalid, "")
+			return
+
+		case domain.ErrKey:
+			// The user is gone, so is any use of the token.
+			render.ErrAccessTokenRevoked(req)
+			return
+
+		case domain.ErrOverloaded:
+			render.ErrOverloaded(req, a.OverloadRetryAfter)
+			return
+
+		default:
+			render.ErrInternal(req, "")
+			return
+		}
+	}
+
+	req.SetContentType("application/json")
+	_, _ = req.WriteString(`{"res":{"terminated":`)
+	_, _ = req.WriteString(strconv.Itoa(cmd.Result.Terminated))
+	_, _ = req.WriteString(`}}`)
+}
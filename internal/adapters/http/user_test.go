@@ -3,6 +3,7 @@ package http
 import (
 	"io"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -23,6 +24,7 @@ func TestUserPost_Response(t *testing.T) {
 	tcs := []tc{
 		{"user exists", domain.ErrExists, `{"err":{"code":"USER_EXISTS"}}`, fasthttp.StatusConflict},
 		{"value error", domain.ErrValue, `{"err":{"code":"VALUE"}}`, fasthttp.StatusBadRequest},
+		{"overloaded", domain.ErrOverloaded, `{"err":{"code":"OVERLOADED"}}`, fasthttp.StatusServiceUnavailable},
 		{"internal error", io.ErrShortWrite /* other */, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
 		{"ok", nil, `{"res":"24h email confirmation"}`, fasthttp.StatusOK},
 	}
@@ -33,7 +35,7 @@ func TestUserPost_Response(t *testing.T) {
 			defer ctrl.Finish()
 
 			userDriver := user.NewMockDriverUser(ctrl)
-			a := Adapter{Users: userDriver}
+			a := Adapter{Users: userDriver, OverloadRetryAfter: time.Millisecond * 1500}
 
 			const reqBody = `
 				{	
@@ -58,6 +60,195 @@ func TestUserPost_Response(t *testing.T) {
 			assert.Equal(t, req.Response.StatusCode(), tc.expResStatus)
 			assert.Equal(t, string(resBody), tc.expRes)
 			assert.Equal(t, string(req.Response.Header.ContentType()), "application/json")
+			if tc.driverErr == domain.ErrOverloaded {
+				assert.Equal(t, "2", string(req.Response.Header.Peek("Retry-After")))
+			}
+		})
+	}
+}
+
+func TestUserEmailConfirmationPost_Response(t *testing.T) {
+	type tc struct {
+		name         string
+		driverErr    error
+		expRes       string
+		expResStatus int
+	}
+	tcs := []tc{
+		{"value error", domain.ErrValue, `{"err":{"code":"VALUE"}}`, fasthttp.StatusBadRequest},
+		{"internal error", io.ErrShortWrite, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{"ok", nil, `{"res":"sent"}`, fasthttp.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userDriver := user.NewMockDriverUser(ctrl)
+			a := Adapter{Users: userDriver}
+
+			userDriver.EXPECT().ResendEmailConfirmation(gomock.Any()).
+				DoAndReturn(func(cmd *ports.CommandUserEmailConfirmationResend) error {
+					assert.Equal(t, "pgarin@old.me", cmd.Email)
+					return tc.driverErr
+				})
+
+			req := &fasthttp.RequestCtx{}
+			req.Request.SetBody([]byte(`{"email": "pgarin@old.me"}`))
+			a.UserEmailConfirmationPost(req)
+
+			assert.Equal(t, tc.expResStatus, req.Response.StatusCode())
+			assert.Equal(t, tc.expRes, string(req.Response.Body()))
+		})
+	}
+}
+
+func TestUserEmailConfirmationConfirmPost_Response(t *testing.T) {
+	type tc struct {
+		name         string
+		driverErr    error
+		expRes       string
+		expResStatus int
+	}
+	tcs := []tc{
+		{"value error", domain.ErrValue, `{"err":{"code":"CONFIRMATION_INVALID"}}`, fasthttp.StatusBadRequest},
+		{"unknown token", domain.ErrKey, `{"err":{"code":"CONFIRMATION_INVALID"}}`, fasthttp.StatusBadRequest},
+		{"expired", domain.ErrExpired, `{"err":{"code":"CONFIRMATION_EXPIRED"}}`, fasthttp.StatusGone},
+		{"internal error", io.ErrShortWrite, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{"ok", nil, `{"res":"confirmed"}`, fasthttp.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userDriver := user.NewMockDriverUser(ctrl)
+			a := Adapter{Users: userDriver}
+
+			userDriver.EXPECT().ConfirmEmail(&ports.CommandUserEmailConfirm{Token: "tok"}).Return(tc.driverErr)
+
+			req := &fasthttp.RequestCtx{}
+			req.Request.SetBody([]byte(`{"token": "tok"}`))
+			a.UserEmailConfirmationConfirmPost(req)
+
+			assert.Equal(t, tc.expResStatus, req.Response.StatusCode())
+			assert.Equal(t, tc.expRes, string(req.Response.Body()))
+		})
+	}
+}
+
+func TestUserPhoneVerifyPost_Response(t *testing.T) {
+	type tc struct {
+		name         string
+		driverErr    error
+		expRes       string
+		expResStatus int
+	}
+	tcs := []tc{
+		{"wrong code", domain.ErrCredentials, `{"err":{"code":"OTP_INVALID"}}`, fasthttp.StatusBadRequest},
+		{"no code", domain.ErrKey, `{"err":{"code":"OTP_EXPIRED"}}`, fasthttp.StatusGone},
+		{"expired", domain.ErrExpired, `{"err":{"code":"OTP_EXPIRED"}}`, fasthttp.StatusGone},
+		{"internal error", io.ErrShortWrite, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{"ok", nil, `{"res":"verified"}`, fasthttp.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userDriver := user.NewMockDriverUser(ctrl)
+			a := Adapter{Users: userDriver}
+
+			userDriver.EXPECT().VerifyPhone(&ports.CommandUserPhoneVerify{UserId: 1, Code: "123456"}).Return(tc.driverErr)
+
+			req := &fasthttp.RequestCtx{}
+			req.Request.SetBody([]byte(`{"code": "123456"}`))
+			a.UserPhoneVerifyPost(req, &domain.SessionHttpToken{User: domain.SessionHttpTokenUser{Id: 1}})
+
+			assert.Equal(t, tc.expResStatus, req.Response.StatusCode())
+			assert.Equal(t, tc.expRes, string(req.Response.Body()))
+		})
+	}
+}
+
+func TestUserPasswordResetConfirmPost_Response(t *testing.T) {
+	type tc struct {
+		name         string
+		driverErr    error
+		expRes       string
+		expResStatus int
+	}
+	tcs := []tc{
+		{"invalid", domain.ErrValue, `{"err":{"code":"VALUE"}}`, fasthttp.StatusBadRequest},
+		{"unknown token", domain.ErrKey, `{"err":{"code":"PASSWORD_RESET_INVALID"}}`, fasthttp.StatusBadRequest},
+		{"expired", domain.ErrExpired, `{"err":{"code":"PASSWORD_RESET_EXPIRED"}}`, fasthttp.StatusGone},
+		{"internal error", io.ErrShortWrite, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{"ok", nil, `{"res":"reset"}`, fasthttp.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userDriver := user.NewMockDriverUser(ctrl)
+			a := Adapter{Users: userDriver}
+
+			userDriver.EXPECT().ResetPassword(&ports.CommandUserPasswordReset{Token: "foo", Password: "qwerty123"}).Return(tc.driverErr)
+
+			req := &fasthttp.RequestCtx{}
+			req.Request.SetBody([]byte(`{"token": "foo", "password": "qwerty123"}`))
+			a.UserPasswordResetConfirmPost(req)
+
+			assert.Equal(t, tc.expResStatus, req.Response.StatusCode())
+			assert.Equal(t, tc.expRes, string(req.Response.Body()))
+		})
+	}
+}
+
+func TestUserPasswordPost_Response(t *testing.T) {
+	type tc struct {
+		name         string
+		driverErr    error
+		expRes       string
+		expResStatus int
+	}
+	tcs := []tc{
+		{"invalid", domain.ErrValue, `{"err":{"code":"VALUE"}}`, fasthttp.StatusBadRequest},
+		{"wrong password", domain.ErrCredentials, `{"err":{"code":"CREDENTIALS_INVALID"}}`, fasthttp.StatusBadRequest},
+		{"no user", domain.ErrKey, `{"err":{"code":"ACCESS_TOKEN_REVOKED"}}`, fasthttp.StatusUnauthorized},
+		{"internal error", io.ErrShortWrite, `{"err":{"code":"INTERNAL"}}`, fasthttp.StatusInternalServerError},
+		{"ok", nil, `{"res":{"terminated":2}}`, fasthttp.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userDriver := user.NewMockDriverUser(ctrl)
+			a := Adapter{Users: userDriver}
+
+			userDriver.EXPECT().ChangePassword(gomock.Any()).
+				DoAndReturn(func(cmd *ports.CommandUserPasswordChange) error {
+					assert.Equal(t, int64(1), cmd.UserId)
+					assert.Equal(t, int64(7), cmd.SessionId)
+					assert.Equal(t, "qwerty123", cmd.CurrentPassword)
+					assert.Equal(t, "123qwerty", cmd.NewPassword)
+					assert.True(t, cmd.TerminateOthers)
+					cmd.Result.Terminated = 2
+					return tc.driverErr
+				})
+
+			req := &fasthttp.RequestCtx{}
+			req.Request.SetBody([]byte(`{"current_password": "qwerty123", "new_password": "123qwerty", "terminate_other_sessions": true}`))
+			a.UserPasswordPost(req, &domain.SessionHttpToken{SessionId: 7, User: domain.SessionHttpTokenUser{Id: 1}})
+
+			assert.Equal(t, tc.expResStatus, req.Response.StatusCode())
+			assert.Equal(t, tc.expRes, string(req.Response.Body()))
 		})
 	}
 }
@@ -0,0 +1,589 @@
+// Package config describes the server settings. The settings are read
+// from an optional JSON file, then overridden by BORIS_* environment
+// variables and validated as a whole.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type Config struct {
+	Http     Http     `json:"http"`
+	Postgres Postgres `json:"postgres"`
+	Token    Token    `json:"token"`
+	Password Password `json:"password"`
+	Session  Session  `json:"session"`
+	User     User     `json:"user"`
+	Texts    Texts    `json:"texts"`
+	Smtp     Smtp     `json:"smtp"`
+	Sms      Sms      `json:"sms"`
+
+	Attestation Attestation `json:"attestation"`
+	RateLimit   RateLimit   `json:"rate_limit"`
+}
+
+type Http struct {
+	Addr      string `json:"addr"`
+	AuthRealm string `json:"auth_realm"`
+	// PolicyPath is an optional route policy file, see
+	// middleware.PolicyFile.
+	PolicyPath string `json:"policy_path"`
+	// TokenSources are the "header", "query" and "cookie" request
+	// parts the access token is taken from.
+	TokenSources []string `json:"token_sources"`
+	// DpopBaseUrl is the scheme and host the clients sign DPoP proofs
+	// for, taken from the requests if empty.
+	DpopBaseUrl string   `json:"dpop_base_url"`
+	DpopSkew    Duration `json:"dpop_skew"`
+	// Cookies lets the browser clients ask the login endpoints for
+	// the access_token cookie instead of the token in the body.
+	Cookies      bool   `json:"cookies"`
+	CookieDomain string `json:"cookie_domain"`
+	CookieSecure bool   `json:"cookie_secure"`
+	// CookieSameSite is "strict", "lax" or "none".
+	CookieSameSite string `json:"cookie_same_site"`
+	// CsrfOrigins are the only Origin header values accepted along
+	// with the cookie if set, e.g. "https://boris.army".
+	CsrfOrigins []string `json:"csrf_origins"`
+}
+
+type Postgres struct {
+	Dsn             string   `json:"dsn"`
+	MaxConns        int32    `json:"max_conns"`
+	MinConns        int32    `json:"min_conns"`
+	MaxConnLifetime Duration `json:"max_conn_lifetime"`
+}
+
+type Token struct {
+	Secret string `json:"secret"`
+}
+
+const (
+	PasswordAlgorithmBCrypt   = "bcrypt"
+	PasswordAlgorithmArgon2id = "argon2id"
+)
+
+// Password selects the hashing policy for new digests. Either
+// algorithm verifies bcrypt digests, outdated ones are rehashed
+// on login.
+type Password struct {
+	Algorithm  string `json:"algorithm"`
+	BCryptCost int    `json:"bcrypt_cost"`
+	// Argon2Memory is in KiB.
+	Argon2Memory      int `json:"argon2_memory"`
+	Argon2Iterations  int `json:"argon2_iterations"`
+	Argon2Parallelism int `json:"argon2_parallelism"`
+	// HashWorkers bounds the number of concurrent hashing calls,
+	// HashQueueSize and HashWait bound the calls waiting for one.
+	HashWorkers   int      `json:"hash_workers"`
+	HashQueueSize int      `json:"hash_queue_size"`
+	HashWait      Duration `json:"hash_wait"`
+}
+
+type Session struct {
+	HttpTtl                     Duration `json:"http_ttl"`
+	TerminatedReindexPeriod     Duration `json:"terminated_reindex_period"`
+	TerminatedFalsePositiveRate float64  `json:"terminated_false_positive_rate"`
+}
+
+type User struct {
+	// EmailConfirmUrl is the link sent for confirmation, the token
+	// is appended to it.
+	EmailConfirmUrl        string   `json:"email_confirm_url"`
+	EmailConfirmTtl        Duration `json:"email_confirm_ttl"`
+	EmailResendInterval    Duration `json:"email_resend_interval"`
+	UnconfirmedPurgePeriod Duration `json:"unconfirmed_purge_period"`
+	PhoneCodeTtl           Duration `json:"phone_code_ttl"`
+	PhoneCodeMaxAttempts   int      `json:"phone_code_max_attempts"`
+	PhoneResendInterval    Duration `json:"phone_resend_interval"`
+	// PasswordResetUrl is the link sent for password reset, the token
+	// is appended to it.
+	PasswordResetUrl      string   `json:"password_reset_url"`
+	PasswordResetTtl      Duration `json:"password_reset_ttl"`
+	PasswordResetInterval Duration `json:"password_reset_interval"`
+	// TotpIssuer names the service in authenticator apps.
+	TotpIssuer string `json:"totp_issuer"`
+	// TotpSecretKey encrypts TOTP secrets at rest.
+	TotpSecretKey string `json:"totp_secret_key"`
+	// TotpSkew is the number of time steps accepted before and
+	// after the current one.
+	TotpSkew                  int      `json:"totp_skew"`
+	LoginChallengeTtl         Duration `json:"login_challenge_ttl"`
+	LoginChallengeMaxAttempts int      `json:"login_challenge_max_attempts"`
+	// LoginFailureWindow is how long failed logins are remembered.
+	LoginFailureWindow Duration `json:"login_failure_window"`
+	// LoginDelayBase is the lock after the first failure past the free
+	// ones, doubled by every next failure up to LoginLockoutTtl.
+	LoginDelayBase         Duration `json:"login_delay_base"`
+	LoginLockoutTtl        Duration `json:"login_lockout_ttl"`
+	LoginFreeFailures      int      `json:"login_free_failures"`
+	LoginLockoutFailures   int      `json:"login_lockout_failures"`
+	LoginIpFreeFailures    int      `json:"login_ip_free_failures"`
+	LoginIpLockoutFailures int      `json:"login_ip_lockout_failures"`
+}
+
+type Texts struct {
+	QueueSize int `json:"queue_size"`
+	Workers   int `json:"workers"`
+}
+
+// Smtp configures email delivery. Messages are logged instead
+// of being sent if Addr is empty.
+type Smtp struct {
+	Addr     string `json:"addr"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+// Sms configures text message delivery through an HTTP gateway.
+// Messages are logged instead of being sent if GatewayUrl is empty.
+type Sms struct {
+	GatewayUrl   string   `json:"gateway_url"`
+	GatewayToken string   `json:"gateway_token"`
+	Timeout      Duration `json:"timeout"`
+}
+
+// RateLimit bounds the request rate of every instance with token
+// buckets. Rates are in requests per second, a zero rate disables
+// the limit.
+type RateLimit struct {
+	// Ip* apply to every request per client address.
+	IpRate  float64 `json:"ip_rate"`
+	IpBurst int     `json:"ip_burst"`
+	// User* apply to the authenticated requests per user.
+	UserRate  float64 `json:"user_rate"`
+	UserBurst int     `json:"user_burst"`
+	// Login* apply to the credential endpoints per endpoint and
+	// client address.
+	LoginRate  float64 `json:"login_rate"`
+	LoginBurst int     `json:"login_burst"`
+}
+
+type Attestation struct {
+	// ReviewerIds are the users allowed to decide on attestations
+	// besides those granted domain.UserRoleReviewer.
+	ReviewerIds []int64 `json:"reviewer_ids"`
+}
+
+// Default returns the settings used when neither the file nor
+// the environment specify a value.
+func Default() *Config {
+	return &Config{
+		Http: Http{
+			Addr:           ":8080",
+			AuthRealm:      "boris.army",
+			TokenSources:   []string{"header", "cookie"},
+			DpopSkew:       Duration(time.Minute),
+			CookieSecure:   true,
+			CookieSameSite: "strict",
+		},
+		Postgres: Postgres{
+			MaxConns:        16,
+			MinConns:        1,
+			MaxConnLifetime: Duration(time.Hour),
+		},
+		Password: Password{
+			Algorithm:         PasswordAlgorithmBCrypt,
+			BCryptCost:        14,
+			Argon2Memory:      64 * 1024,
+			Argon2Iterations:  3,
+			Argon2Parallelism: 4,
+			HashWorkers:       runtime.NumCPU(),
+			HashQueueSize:     256,
+			HashWait:          Duration(time.Second * 2),
+		},
+		Session: Session{
+			HttpTtl:                     Duration(time.Hour * 24 * 30),
+			TerminatedReindexPeriod:     Duration(time.Minute * 3),
+			TerminatedFalsePositiveRate: .0001,
+		},
+		User: User{
+			EmailConfirmTtl:           Duration(time.Hour * 24),
+			EmailResendInterval:       Duration(time.Minute),
+			UnconfirmedPurgePeriod:    Duration(time.Hour),
+			PhoneCodeTtl:              Duration(time.Minute * 10),
+			PhoneCodeMaxAttempts:      5,
+			PhoneResendInterval:       Duration(time.Minute),
+			PasswordResetTtl:          Duration(time.Hour),
+			PasswordResetInterval:     Duration(time.Minute),
+			TotpIssuer:                "Boris",
+			TotpSkew:                  1,
+			LoginChallengeTtl:         Duration(time.Minute * 5),
+			LoginChallengeMaxAttempts: 5,
+			LoginFailureWindow:        Duration(time.Hour),
+			LoginDelayBase:            Duration(time.Second),
+			LoginLockoutTtl:           Duration(time.Minute * 15),
+			LoginFreeFailures:         3,
+			LoginLockoutFailures:      10,
+			LoginIpFreeFailures:       20,
+			LoginIpLockoutFailures:    100,
+		},
+		Texts: Texts{
+			QueueSize: 1024,
+			Workers:   4,
+		},
+		Sms: Sms{
+			Timeout: Duration(time.Second * 10),
+		},
+		RateLimit: RateLimit{
+			IpRate:     20,
+			IpBurst:    40,
+			UserRate:   10,
+			UserBurst:  20,
+			LoginRate:  .2,
+			LoginBurst: 10,
+		},
+	}
+}
+
+// Load reads the settings from the given JSON file (skipped if the path
+// is empty), applies the environment overrides and validates the result.
+func Load(path string) (*Config, error) {
+	c := Default()
+	if len(path) > 0 {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			return nil, fmt.Errorf("config: can't decode %s: %w", path, err)
+		}
+	}
+	if err := c.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ApplyEnv overrides the settings with the variables found by lookup.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	vars := []struct {
+		key   string
+		parse func(string) error
+	}{
+		{"BORIS_HTTP_ADDR", parseString(&c.Http.Addr)},
+		{"BORIS_HTTP_AUTH_REALM", parseString(&c.Http.AuthRealm)},
+		{"BORIS_HTTP_POLICY_PATH", parseString(&c.Http.PolicyPath)},
+		{"BORIS_HTTP_TOKEN_SOURCES", parseStrings(&c.Http.TokenSources)},
+		{"BORIS_HTTP_DPOP_BASE_URL", parseString(&c.Http.DpopBaseUrl)},
+		{"BORIS_HTTP_DPOP_SKEW", parseDuration(&c.Http.DpopSkew)},
+		{"BORIS_HTTP_COOKIES", parseBool(&c.Http.Cookies)},
+		{"BORIS_HTTP_COOKIE_DOMAIN", parseString(&c.Http.CookieDomain)},
+		{"BORIS_HTTP_COOKIE_SECURE", parseBool(&c.Http.CookieSecure)},
+		{"BORIS_HTTP_COOKIE_SAME_SITE", parseString(&c.Http.CookieSameSite)},
+		{"BORIS_HTTP_CSRF_ORIGINS", parseStrings(&c.Http.CsrfOrigins)},
+		{"BORIS_POSTGRES_DSN", parseString(&c.Postgres.Dsn)},
+		{"BORIS_POSTGRES_MAX_CONNS", parseInt32(&c.Postgres.MaxConns)},
+		{"BORIS_POSTGRES_MIN_CONNS", parseInt32(&c.Postgres.MinConns)},
+		{"BORIS_POSTGRES_MAX_CONN_LIFETIME", parseDuration(&c.Postgres.MaxConnLifetime)},
+		{"BORIS_TOKEN_SECRET", parseString(&c.Token.Secret)},
+		{"BORIS_PASSWORD_ALGORITHM", parseString(&c.Password.Algorithm)},
+		{"BORIS_PASSWORD_BCRYPT_COST", parseInt(&c.Password.BCryptCost)},
+		{"BORIS_PASSWORD_ARGON2_MEMORY", parseInt(&c.Password.Argon2Memory)},
+		{"BORIS_PASSWORD_ARGON2_ITERATIONS", parseInt(&c.Password.Argon2Iterations)},
+		{"BORIS_PASSWORD_ARGON2_PARALLELISM", parseInt(&c.Password.Argon2Parallelism)},
+		{"BORIS_PASSWORD_HASH_WORKERS", parseInt(&c.Password.HashWorkers)},
+		{"BORIS_PASSWORD_HASH_QUEUE_SIZE", parseInt(&c.Password.HashQueueSize)},
+		{"BORIS_PASSWORD_HASH_WAIT", parseDuration(&c.Password.HashWait)},
+		{"BORIS_SESSION_HTTP_TTL", parseDuration(&c.Session.HttpTtl)},
+		{"BORIS_SESSION_TERMINATED_REINDEX_PERIOD", parseDuration(&c.Session.TerminatedReindexPeriod)},
+		{"BORIS_SESSION_TERMINATED_FALSE_POSITIVE_RATE", parseFloat(&c.Session.TerminatedFalsePositiveRate)},
+		{"BORIS_USER_EMAIL_CONFIRM_URL", parseString(&c.User.EmailConfirmUrl)},
+		{"BORIS_USER_EMAIL_CONFIRM_TTL", parseDuration(&c.User.EmailConfirmTtl)},
+		{"BORIS_USER_EMAIL_RESEND_INTERVAL", parseDuration(&c.User.EmailResendInterval)},
+		{"BORIS_USER_UNCONFIRMED_PURGE_PERIOD", parseDuration(&c.User.UnconfirmedPurgePeriod)},
+		{"BORIS_USER_PHONE_CODE_TTL", parseDuration(&c.User.PhoneCodeTtl)},
+		{"BORIS_USER_PHONE_CODE_MAX_ATTEMPTS", parseInt(&c.User.PhoneCodeMaxAttempts)},
+		{"BORIS_USER_PHONE_RESEND_INTERVAL", parseDuration(&c.User.PhoneResendInterval)},
+		{"BORIS_USER_PASSWORD_RESET_URL", parseString(&c.User.PasswordResetUrl)},
+		{"BORIS_USER_PASSWORD_RESET_TTL", parseDuration(&c.User.PasswordResetTtl)},
+		{"BORIS_USER_PASSWORD_RESET_INTERVAL", parseDuration(&c.User.PasswordResetInterval)},
+		{"BORIS_USER_TOTP_ISSUER", parseString(&c.User.TotpIssuer)},
+		{"BORIS_USER_TOTP_SECRET_KEY", parseString(&c.User.TotpSecretKey)},
+		{"BORIS_USER_TOTP_SKEW", parseInt(&c.User.TotpSkew)},
+		{"BORIS_USER_LOGIN_CHALLENGE_TTL", parseDuration(&c.User.LoginChallengeTtl)},
+		{"BORIS_USER_LOGIN_CHALLENGE_MAX_ATTEMPTS", parseInt(&c.User.LoginChallengeMaxAttempts)},
+		{"BORIS_USER_LOGIN_FAILURE_WINDOW", parseDuration(&c.User.LoginFailureWindow)},
+		{"BORIS_USER_LOGIN_DELAY_BASE", parseDuration(&c.User.LoginDelayBase)},
+		{"BORIS_USER_LOGIN_LOCKOUT_TTL", parseDuration(&c.User.LoginLockoutTtl)},
+		{"BORIS_USER_LOGIN_FREE_FAILURES", parseInt(&c.User.LoginFreeFailures)},
+		{"BORIS_USER_LOGIN_LOCKOUT_FAILURES", parseInt(&c.User.LoginLockoutFailures)},
+		{"BORIS_USER_LOGIN_IP_FREE_FAILURES", parseInt(&c.User.LoginIpFreeFailures)},
+		{"BORIS_USER_LOGIN_IP_LOCKOUT_FAILURES", parseInt(&c.User.LoginIpLockoutFailures)},
+		{"BORIS_TEXTS_QUEUE_SIZE", parseInt(&c.Texts.QueueSize)},
+		{"BORIS_TEXTS_WORKERS", parseInt(&c.Texts.Workers)},
+		{"BORIS_SMTP_ADDR", parseString(&c.Smtp.Addr)},
+		{"BORIS_SMTP_USERNAME", parseString(&c.Smtp.Username)},
+		{"BORIS_SMTP_PASSWORD", parseString(&c.Smtp.Password)},
+		{"BORIS_SMTP_FROM", parseString(&c.Smtp.From)},
+		{"BORIS_SMS_GATEWAY_URL", parseString(&c.Sms.GatewayUrl)},
+		{"BORIS_SMS_GATEWAY_TOKEN", parseString(&c.Sms.GatewayToken)},
+		{"BORIS_SMS_TIMEOUT", parseDuration(&c.Sms.Timeout)},
+		{"BORIS_ATTESTATION_REVIEWER_IDS", parseInt64s(&c.Attestation.ReviewerIds)},
+		{"BORIS_RATE_LIMIT_IP_RATE", parseFloat(&c.RateLimit.IpRate)},
+		{"BORIS_RATE_LIMIT_IP_BURST", parseInt(&c.RateLimit.IpBurst)},
+		{"BORIS_RATE_LIMIT_USER_RATE", parseFloat(&c.RateLimit.UserRate)},
+		{"BORIS_RATE_LIMIT_USER_BURST", parseInt(&c.RateLimit.UserBurst)},
+		{"BORIS_RATE_LIMIT_LOGIN_RATE", parseFloat(&c.RateLimit.LoginRate)},
+		{"BORIS_RATE_LIMIT_LOGIN_BURST", parseInt(&c.RateLimit.LoginBurst)},
+	}
+	for _, v := range vars {
+		s, ok := lookup(v.key)
+		if !ok {
+			continue
+		}
+		if err := v.parse(s); err != nil {
+			return fmt.Errorf("config: invalid %s: %w", v.key, err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) Validate() error {
+	switch {
+	case len(c.Http.Addr) == 0:
+		return fmt.Errorf("config: http.addr is required")
+	case len(c.Http.AuthRealm) == 0:
+		return fmt.Errorf("config: http.auth_realm is required")
+	case len(c.Http.TokenSources) == 0:
+		return fmt.Errorf("config: http.token_sources is required")
+	case c.Http.DpopSkew <= 0:
+		return fmt.Errorf("config: http.dpop_skew must be positive")
+	case c.Http.CookieSameSite != "strict" && c.Http.CookieSameSite != "lax" && c.Http.CookieSameSite != "none":
+		return fmt.Errorf("config: http.cookie_same_site must be \"strict\", \"lax\" or \"none\"")
+	case c.Http.CookieSameSite == "none" && !c.Http.CookieSecure:
+		return fmt.Errorf("config: http.cookie_secure is required with \"none\" http.cookie_same_site")
+	case len(c.Postgres.Dsn) == 0:
+		return fmt.Errorf("config: postgres.dsn is required")
+	case c.Postgres.MaxConns < 1:
+		return fmt.Errorf("config: postgres.max_conns must be positive")
+	case c.Postgres.MinConns < 0 || c.Postgres.MinConns > c.Postgres.MaxConns:
+		return fmt.Errorf("config: postgres.min_conns must be within [0, max_conns]")
+	case c.Postgres.MaxConnLifetime <= 0:
+		return fmt.Errorf("config: postgres.max_conn_lifetime must be positive")
+	case len(c.Token.Secret) < 32:
+		return fmt.Errorf("config: token.secret must be at least 32 bytes long")
+	case c.Password.BCryptCost < bcrypt.MinCost || c.Password.BCryptCost > bcrypt.MaxCost:
+		return fmt.Errorf("config: password.bcrypt_cost must be within [%d, %d]", bcrypt.MinCost, bcrypt.MaxCost)
+	case c.Password.Algorithm != PasswordAlgorithmBCrypt && c.Password.Algorithm != PasswordAlgorithmArgon2id:
+		return fmt.Errorf("config: password.algorithm must be %q or %q", PasswordAlgorithmBCrypt, PasswordAlgorithmArgon2id)
+	case c.Password.Argon2Parallelism < 1 || c.Password.Argon2Parallelism > 255:
+		return fmt.Errorf("config: password.argon2_parallelism must be within [1, 255]")
+	case c.Password.Argon2Memory < 8*c.Password.Argon2Parallelism || int64(c.Password.Argon2Memory) > math.MaxUint32:
+		return fmt.Errorf("config: password.argon2_memory must be at least 8 KiB per lane")
+	case c.Password.Argon2Iterations < 1 || int64(c.Password.Argon2Iterations) > math.MaxUint32:
+		return fmt.Errorf("config: password.argon2_iterations must be positive")
+	case c.Password.HashWorkers < 1:
+		return fmt.Errorf("config: password.hash_workers must be positive")
+	case c.Password.HashQueueSize < 0:
+		return fmt.Errorf("config: password.hash_queue_size must not be negative")
+	case c.Password.HashWait <= 0:
+		return fmt.Errorf("config: password.hash_wait must be positive")
+	case c.Session.HttpTtl <= 0:
+		return fmt.Errorf("config: session.http_ttl must be positive")
+	case c.Session.TerminatedReindexPeriod <= 0:
+		return fmt.Errorf("config: session.terminated_reindex_period must be positive")
+	case c.Session.TerminatedFalsePositiveRate <= 0 || c.Session.TerminatedFalsePositiveRate >= 1:
+		return fmt.Errorf("config: session.terminated_false_positive_rate must be within (0, 1)")
+	case len(c.User.EmailConfirmUrl) == 0:
+		return fmt.Errorf("config: user.email_confirm_url is required")
+	case c.User.EmailConfirmTtl <= 0:
+		return fmt.Errorf("config: user.email_confirm_ttl must be positive")
+	case c.User.EmailResendInterval < 0:
+		return fmt.Errorf("config: user.email_resend_interval must not be negative")
+	case c.User.UnconfirmedPurgePeriod <= 0:
+		return fmt.Errorf("config: user.unconfirmed_purge_period must be positive")
+	case c.User.PhoneCodeTtl <= 0:
+		return fmt.Errorf("config: user.phone_code_ttl must be positive")
+	case c.User.PhoneCodeMaxAttempts < 1:
+		return fmt.Errorf("config: user.phone_code_max_attempts must be positive")
+	case c.User.PhoneResendInterval < 0:
+		return fmt.Errorf("config: user.phone_resend_interval must not be negative")
+	case len(c.User.PasswordResetUrl) == 0:
+		return fmt.Errorf("config: user.password_reset_url is required")
+	case c.User.PasswordResetTtl <= 0:
+		return fmt.Errorf("config: user.password_reset_ttl must be positive")
+	case c.User.PasswordResetInterval < 0:
+		return fmt.Errorf("config: user.password_reset_interval must not be negative")
+	case len(c.User.TotpIssuer) == 0:
+		return fmt.Errorf("config: user.totp_issuer is required")
+	case len(c.User.TotpSecretKey) < 32:
+		return fmt.Errorf("config: user.totp_secret_key must be at least 32 bytes long")
+	case c.User.TotpSkew < 0:
+		return fmt.Errorf("config: user.totp_skew must not be negative")
+	case c.User.LoginChallengeTtl <= 0:
+		return fmt.Errorf("config: user.login_challenge_ttl must be positive")
+	case c.User.LoginChallengeMaxAttempts < 1:
+		return fmt.Errorf("config: user.login_challenge_max_attempts must be positive")
+	case c.User.LoginFailureWindow <= 0:
+		return fmt.Errorf("config: user.login_failure_window must be positive")
+	case c.User.LoginDelayBase <= 0:
+		return fmt.Errorf("config: user.login_delay_base must be positive")
+	case c.User.LoginLockoutTtl < c.User.LoginDelayBase:
+		return fmt.Errorf("config: user.login_lockout_ttl must be at least user.login_delay_base")
+	case c.User.LoginFreeFailures < 0 || c.User.LoginLockoutFailures <= c.User.LoginFreeFailures:
+		return fmt.Errorf("config: user.login_lockout_failures must exceed user.login_free_failures")
+	case c.User.LoginIpFreeFailures < 0 || c.User.LoginIpLockoutFailures <= c.User.LoginIpFreeFailures:
+		return fmt.Errorf("config: user.login_ip_lockout_failures must exceed user.login_ip_free_failures")
+	case c.Texts.QueueSize < 0:
+		return fmt.Errorf("config: texts.queue_size must not be negative")
+	case c.Texts.Workers < 1:
+		return fmt.Errorf("config: texts.workers must be positive")
+	case len(c.Smtp.Addr) > 0 && len(c.Smtp.From) == 0:
+		return fmt.Errorf("config: smtp.from is required with smtp.addr")
+	case c.Sms.Timeout <= 0:
+		return fmt.Errorf("config: sms.timeout must be positive")
+	case c.RateLimit.IpRate < 0 || c.RateLimit.IpRate > 0 && c.RateLimit.IpBurst < 1:
+		return fmt.Errorf("config: rate_limit.ip_burst must be positive with non-negative rate_limit.ip_rate")
+	case c.RateLimit.UserRate < 0 || c.RateLimit.UserRate > 0 && c.RateLimit.UserBurst < 1:
+		return fmt.Errorf("config: rate_limit.user_burst must be positive with non-negative rate_limit.user_rate")
+	case c.RateLimit.LoginRate < 0 || c.RateLimit.LoginRate > 0 && c.RateLimit.LoginBurst < 1:
+		return fmt.Errorf("config: rate_limit.login_burst must be positive with non-negative rate_limit.login_rate")
+	}
+	// The names are those of middleware.ParseTokenSources, the config
+	// doesn't depend on the adapters.
+	hasCookieSource := false
+	for _, source := range c.Http.TokenSources {
+		if source != "header" && source != "query" && source != "cookie" {
+			return fmt.Errorf("config: http.token_sources must be of \"header\", \"query\" and \"cookie\"")
+		}
+		hasCookieSource = hasCookieSource || source == "cookie"
+	}
+	if c.Http.Cookies && !hasCookieSource {
+		return fmt.Errorf("config: http.token_sources must have \"cookie\" with http.cookies")
+	}
+	for _, id := range c.Attestation.ReviewerIds {
+		if id < 1 {
+			return fmt.Errorf("config: attestation.reviewer_ids must be positive")
+		}
+	}
+	return nil
+}
+
+// Duration is a time.Duration (un)marshalled as a string like "3m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return parseDuration(d)(s)
+}
+
+func parseString(dst *string) func(string) error {
+	return func(s string) error {
+		*dst = s
+		return nil
+	}
+}
+
+func parseInt(dst *int) func(string) error {
+	return func(s string) error {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		*dst = v
+		return nil
+	}
+}
+
+func parseInt32(dst *int32) func(string) error {
+	return func(s string) error {
+		v, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return err
+		}
+		*dst = int32(v)
+		return nil
+	}
+}
+
+func parseBool(dst *bool) func(string) error {
+	return func(s string) error {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		*dst = v
+		return nil
+	}
+}
+
+// parseInt64s parses a comma separated list, e.g. "1,2,3".
+func parseInt64s(dst *[]int64) func(string) error {
+	return func(s string) error {
+		var vs []int64
+		for _, f := range strings.Split(s, ",") {
+			f = strings.TrimSpace(f)
+			if len(f) == 0 {
+				continue
+			}
+			v, err := strconv.ParseInt(f, 10, 64)
+			if err != nil {
+				return err
+			}
+			vs = append(vs, v)
+		}
+		*dst = vs
+		return nil
+	}
+}
+
+// parseStrings parses a comma separated list, e.g. "a,b,c".
+func parseStrings(dst *[]string) func(string) error {
+	return func(s string) error {
+		var vs []string
+		for _, f := range strings.Split(s, ",") {
+			f = strings.TrimSpace(f)
+			if len(f) > 0 {
+				vs = append(vs, f)
+			}
+		}
+		*dst = vs
+		return nil
+	}
+}
+
+func parseFloat(dst *float64) func(string) error {
+	return func(s string) error {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*dst = v
+		return nil
+	}
+}
+
+func parseDuration(dst *Duration) func(string) error {
+	return func(s string) error {
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*dst = Duration(v)
+		return nil
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad_FileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	const data = `
+		{
+			"postgres": {"dsn": "postgres://localhost/boris"},
+			"token": {"secret": "0123456789abcdef0123456789abcdef"},
+			"session": {"http_ttl": "1h"},
+			"user": {
+				"email_confirm_url": "https://boris.army/confirm?token=",
+				"password_reset_url": "https://boris.army/reset-password?token=",
+				"totp_secret_key": "0123456789abcdef0123456789abcdef"
+			}
+		}
+	`
+	assert.Nil(t, os.WriteFile(path, []byte(data), 0600))
+
+	t.Setenv("BORIS_PASSWORD_BCRYPT_COST", "10")
+	t.Setenv("BORIS_SESSION_HTTP_TTL", "2h")
+	t.Setenv("BORIS_ATTESTATION_REVIEWER_IDS", "1, 2")
+	t.Setenv("BORIS_HTTP_TOKEN_SOURCES", "header")
+	t.Setenv("BORIS_HTTP_COOKIE_SECURE", "false")
+
+	c, err := Load(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "postgres://localhost/boris", c.Postgres.Dsn)
+	assert.Equal(t, 10, c.Password.BCryptCost)
+	assert.Equal(t, Duration(time.Hour*2), c.Session.HttpTtl)
+	assert.Equal(t, Duration(time.Minute*3), c.Session.TerminatedReindexPeriod)
+	assert.Equal(t, []int64{1, 2}, c.Attestation.ReviewerIds)
+	assert.Equal(t, []string{"header"}, c.Http.TokenSources)
+	assert.False(t, c.Http.CookieSecure)
+}
+
+func TestLoad_UnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"foo": 1}`), 0600))
+
+	_, err := Load(path)
+	assert.NotNil(t, err)
+}
+
+func TestConfig_ApplyEnv_Invalid(t *testing.T) {
+	c := Default()
+	err := c.ApplyEnv(func(key string) (string, bool) {
+		return "abc", key == "BORIS_SESSION_HTTP_TTL"
+	})
+	assert.NotNil(t, err)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := func() *Config {
+		c := Default()
+		c.Postgres.Dsn = "postgres://localhost/boris"
+		c.Token.Secret = "0123456789abcdef0123456789abcdef"
+		c.User.EmailConfirmUrl = "https://boris.army/confirm?token="
+		c.User.PasswordResetUrl = "https://boris.army/reset-password?token="
+		c.User.TotpSecretKey = "0123456789abcdef0123456789abcdef"
+		return c
+	}
+	assert.Nil(t, valid().Validate())
+
+	type tc struct {
+		name   string
+		mutate func(*Config)
+	}
+	tcs := []tc{
+		{"unknown token source", func(c *Config) { c.Http.TokenSources = []string{"body"} }},
+		{"cookies without source", func(c *Config) { c.Http.Cookies, c.Http.TokenSources = true, []string{"header"} }},
+		{"cookie same site", func(c *Config) { c.Http.CookieSameSite = "foo" }},
+		{"insecure same site none", func(c *Config) { c.Http.CookieSameSite, c.Http.CookieSecure = "none", false }},
+		{"no dsn", func(c *Config) { c.Postgres.Dsn = "" }},
+		{"short secret", func(c *Config) { c.Token.Secret = "foo" }},
+		{"bcrypt cost", func(c *Config) { c.Password.BCryptCost = 64 }},
+		{"password algorithm", func(c *Config) { c.Password.Algorithm = "md5" }},
+		{"argon2 memory", func(c *Config) { c.Password.Argon2Memory = 1 }},
+		{"hash workers", func(c *Config) { c.Password.HashWorkers = 0 }},
+		{"session ttl", func(c *Config) { c.Session.HttpTtl = 0 }},
+		{"false positive rate", func(c *Config) { c.Session.TerminatedFalsePositiveRate = 1 }},
+		{"no confirm url", func(c *Config) { c.User.EmailConfirmUrl = "" }},
+		{"no password reset url", func(c *Config) { c.User.PasswordResetUrl = "" }},
+		{"short totp secret key", func(c *Config) { c.User.TotpSecretKey = "foo" }},
+		{"login lockout failures", func(c *Config) { c.User.LoginLockoutFailures = c.User.LoginFreeFailures }},
+		{"negative rate limit", func(c *Config) { c.RateLimit.IpRate = -1 }},
+		{"rate limit burst", func(c *Config) { c.RateLimit.UserBurst = 0 }},
+		{"smtp without from", func(c *Config) { c.Smtp.Addr = "localhost:25" }},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := valid()
+			tc.mutate(c)
+			assert.NotNil(t, c.Validate())
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+type AttestationStatus = int
+
+const (
+	AttestationStatusUndef AttestationStatus = iota
+	AttestationStatusPending
+	AttestationStatusApproved
+	AttestationStatusRejected
+	AttestationStatusRevoked
+)
+
+// UserAttestation is a request of the user to be granted UserProofBoris.
+// Reviewer fields are set once the request is decided.
+type UserAttestation struct {
+	Id          int64
+	UserId      int64
+	Status      AttestationStatus
+	Note        string
+	SubmittedAt time.Time
+	ReviewerId  int64
+	Reason      string
+	DecidedAt   time.Time
+}
+
+func (a *UserAttestation) Reset() {
+	a.Id = 0
+	a.UserId = 0
+	a.Status = 0
+	a.Note = ""
+	a.SubmittedAt = time.Time{}
+	a.ReviewerId = 0
+	a.Reason = ""
+	a.DecidedAt = time.Time{}
+}
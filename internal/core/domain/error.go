@@ -8,4 +8,8 @@ var (
 	ErrKey               = errors.New("the key does not exist")
 	ErrExpired           = errors.New("the key has expired")
 	ErrSessionTerminated = errors.New("the session had been terminated")
+	ErrCredentials       = errors.New("invalid credentials")
+	ErrThrottled         = errors.New("too many attempts, try again later")
+	ErrOverloaded        = errors.New("the server is overloaded, try again later")
+	ErrSecondFactor      = errors.New("second factor required")
 )
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"net"
+	"time"
+)
+
+// LoginThrottle counts the failed logins of an account or a client
+// address, keyed by "email:" or "ip:" prefixed values.
+type LoginThrottle struct {
+	Key          string
+	Failures     int
+	LastFailedAt time.Time
+	LockedUntil  time.Time
+}
+
+func (t *LoginThrottle) Reset() {
+	t.Key = ""
+	t.Failures = 0
+	t.LastFailedAt = time.Time{}
+	t.LockedUntil = time.Time{}
+}
+
+// ClientAddr returns the address the client is counted by, empty if
+// the address is unknown. IPv6 clients usually own a whole /64, so it
+// is counted as one.
+func ClientAddr(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.String()
+	}
+	if len(ip) == net.IPv6len {
+		return ip.Mask(net.CIDRMask(64, 128)).String()
+	}
+	return ""
+}
@@ -48,44 +48,73 @@ func (w *SessionHttp) Reset() {
 	w.UserAgent = ""
 }
 
+// SessionHttpToken is the access token of an http session. The user
+// roles, proofs and scopes are copied at the session creation and
+// don't follow later changes of the user: whatever takes them away
+// must terminate the user sessions, as revoking an attestation does.
+//
 //easyjson:json
 type SessionHttpToken struct {
 	SessionId int64                `json:"sid"`
 	User      SessionHttpTokenUser `json:"usr"`
-	ExpiresAt int64                `json:"exp"`
-	Ctx       actkn.Ctx            `json:"-"`
+	Scopes    []string             `json:"scp,omitempty"`
+	// Jkt is the JWK thumbprint of the client key the token is bound
+	// to with DPoP (RFC 9449), empty for bearer tokens.
+	Jkt       string    `json:"jkt,omitempty"`
+	ExpiresAt int64     `json:"exp"`
+	Ctx       actkn.Ctx `json:"-"`
+}
+
+// HasScope tells whether the token is good for the scope.
+func (t *SessionHttpToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 func (t *SessionHttpToken) Reset() {
 	t.SessionId = 0
 	t.User.Reset()
+	t.Scopes = t.Scopes[:0]
+	t.Jkt = ""
 	t.ExpiresAt = 0
-	t.Ctx.Reset()
+	if t.Ctx.Hash != nil {
+		t.Ctx.Reset()
+	}
 }
 
 //easyjson:json
 type SessionHttpTokenUser struct {
-	Id    int64  `json:"id"`
-	Email string `json:"email,nocopy"`
+	Id       int64     `json:"id"`
+	Email    string    `json:"email,nocopy"`
+	Roles    UserRole  `json:"rol,omitempty"`
+	HasProof UserProof `json:"prf,omitempty"`
 }
 
 func (u *SessionHttpTokenUser) Reset() {
 	u.Id = 0
 	u.Email = ""
+	u.Roles = 0
+	u.HasProof = 0
 }
 
 // HTTP tokens have a separate pool to reduce memory expense
 // due to their widespread usage.
 var sessionHttpTokenPool = sync.Pool{
 	New: func() any {
-		return &SessionHttpToken{}
+		return &SessionHttpToken{Ctx: *actkn.NewCtx()}
 	},
 }
 
+// AcquireSessionHttpToken returns a token with Ctx ready for decoding.
 func AcquireSessionHttpToken() *SessionHttpToken {
 	return sessionHttpTokenPool.Get().(*SessionHttpToken)
 }
 
 func ReleaseSessionHttpToken(t *SessionHttpToken) {
+	t.Reset()
 	sessionHttpTokenPool.Put(t)
 }
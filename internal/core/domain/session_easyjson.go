@@ -40,6 +40,10 @@ func easyjsonA818f49aDecodeGithubComBorisArmyServerInternalCoreDomain(in *jlexer
 			out.Id = int64(in.Int64())
 		case "email":
 			out.Email = string(in.UnsafeString())
+		case "rol":
+			out.Roles = int(in.Int())
+		case "prf":
+			out.HasProof = int(in.Int())
 		default:
 			in.SkipRecursive()
 		}
@@ -64,6 +68,16 @@ func easyjsonA818f49aEncodeGithubComBorisArmyServerInternalCoreDomain(out *jwrit
 		out.RawString(prefix)
 		out.String(string(in.Email))
 	}
+	if in.Roles != 0 {
+		const prefix string = ",\"rol\":"
+		out.RawString(prefix)
+		out.Int(int(in.Roles))
+	}
+	if in.HasProof != 0 {
+		const prefix string = ",\"prf\":"
+		out.RawString(prefix)
+		out.Int(int(in.HasProof))
+	}
 	out.RawByte('}')
 }
 
@@ -113,6 +127,31 @@ func easyjsonA818f49aDecodeGithubComBorisArmyServerInternalCoreDomain1(in *jlexe
 			out.SessionId = int64(in.Int64())
 		case "usr":
 			(out.User).UnmarshalEasyJSON(in)
+		case "scp":
+			if in.IsNull() {
+				in.Skip()
+				out.Scopes = nil
+			} else {
+				in.Delim('[')
+				if out.Scopes == nil {
+					if !in.IsDelim(']') {
+						out.Scopes = make([]string, 0, 4)
+					} else {
+						out.Scopes = []string{}
+					}
+				} else {
+					out.Scopes = (out.Scopes)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 string
+					v1 = string(in.String())
+					out.Scopes = append(out.Scopes, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "jkt":
+			out.Jkt = string(in.String())
 		case "exp":
 			out.ExpiresAt = int64(in.Int64())
 		default:
@@ -139,6 +178,25 @@ func easyjsonA818f49aEncodeGithubComBorisArmyServerInternalCoreDomain1(out *jwri
 		out.RawString(prefix)
 		(in.User).MarshalEasyJSON(out)
 	}
+	if len(in.Scopes) != 0 {
+		const prefix string = ",\"scp\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v2, v3 := range in.Scopes {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v3))
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Jkt != "" {
+		const prefix string = ",\"jkt\":"
+		out.RawString(prefix)
+		out.String(string(in.Jkt))
+	}
 	{
 		const prefix string = ",\"exp\":"
 		out.RawString(prefix)
@@ -13,6 +13,37 @@ const (
 	UserProofBoris
 )
 
+// UserRole is a set of roles granted to the user by the operators.
+// The session tokens keep the roles of the login, so taking a role
+// away takes terminating the user sessions as well.
+type UserRole = int
+
+const (
+	UserRoleNone     UserRole = 0
+	UserRoleReviewer UserRole = 1 << iota
+	UserRoleAdmin
+)
+
+// Scopes name the operations an access token is good for.
+const (
+	ScopeAccount            = "account"
+	ScopeAttestationsReview = "attestations:review"
+	ScopeUsersAdmin         = "users:admin"
+)
+
+// UserRoleScopes appends the scopes granted by the roles to dst.
+// Every user gets ScopeAccount.
+func UserRoleScopes(dst []string, roles UserRole) []string {
+	dst = append(dst, ScopeAccount)
+	if roles&(UserRoleReviewer|UserRoleAdmin) != 0 {
+		dst = append(dst, ScopeAttestationsReview)
+	}
+	if roles&UserRoleAdmin != 0 {
+		dst = append(dst, ScopeUsersAdmin)
+	}
+	return dst
+}
+
 type User struct {
 	Id             int64
 	Email          string
@@ -21,8 +52,16 @@ type User struct {
 	Phone164       uint64
 	BornAt         time.Time
 	HasProof       UserProof
+	Roles          UserRole
 	PasswordDigest []byte
 	CreatedAt      time.Time
+	// TotpSecret is encrypted. It is set with TotpEnabled unset
+	// while the enrollment awaits confirmation.
+	TotpSecret  []byte
+	TotpEnabled bool
+	// TotpLastStep is the last time step used, codes of this and
+	// earlier steps are rejected.
+	TotpLastStep int64
 }
 
 func (u *User) Reset() {
@@ -33,6 +72,94 @@ func (u *User) Reset() {
 	u.Phone164 = 0
 	u.BornAt = time.Time{}
 	u.HasProof = 0
+	u.Roles = 0
 	u.PasswordDigest = u.PasswordDigest[:0]
 	u.CreatedAt = time.Time{}
+	u.TotpSecret = u.TotpSecret[:0]
+	u.TotpEnabled = false
+	u.TotpLastStep = 0
+}
+
+// UserEmailConfirmation is a pending email confirmation. Only the
+// digest of the token sent to the user is stored.
+type UserEmailConfirmation struct {
+	UserId      int64
+	TokenDigest []byte
+	SentAt      time.Time
+	ExpiresAt   time.Time
+}
+
+func (c *UserEmailConfirmation) Reset() {
+	c.UserId = 0
+	c.TokenDigest = c.TokenDigest[:0]
+	c.SentAt = time.Time{}
+	c.ExpiresAt = time.Time{}
+}
+
+// UserPhoneVerification is a pending phone number verification.
+// Only the digest of the code sent to the phone is stored.
+type UserPhoneVerification struct {
+	UserId     int64
+	Phone164   uint64
+	CodeDigest []byte
+	Attempts   int
+	SentAt     time.Time
+	ExpiresAt  time.Time
+}
+
+func (v *UserPhoneVerification) Reset() {
+	v.UserId = 0
+	v.Phone164 = 0
+	v.CodeDigest = v.CodeDigest[:0]
+	v.Attempts = 0
+	v.SentAt = time.Time{}
+	v.ExpiresAt = time.Time{}
+}
+
+// UserPasswordReset is a pending password reset. Only the digest
+// of the token sent to the user is stored.
+type UserPasswordReset struct {
+	UserId      int64
+	TokenDigest []byte
+	SentAt      time.Time
+	ExpiresAt   time.Time
+}
+
+func (r *UserPasswordReset) Reset() {
+	r.UserId = 0
+	r.TokenDigest = r.TokenDigest[:0]
+	r.SentAt = time.Time{}
+	r.ExpiresAt = time.Time{}
+}
+
+// UserLoginChallenge is a login awaiting the second factor. Only the
+// digest of the token given to the client is stored.
+type UserLoginChallenge struct {
+	TokenDigest []byte
+	UserId      int64
+	Attempts    int
+	ExpiresAt   time.Time
+}
+
+func (c *UserLoginChallenge) Reset() {
+	c.TokenDigest = c.TokenDigest[:0]
+	c.UserId = 0
+	c.Attempts = 0
+	c.ExpiresAt = time.Time{}
+}
+
+// UserRecoveryCode replaces a TOTP code once. Only the digest of the
+// code is stored.
+type UserRecoveryCode struct {
+	Id         int64
+	UserId     int64
+	CodeDigest []byte
+	UsedAt     time.Time
+}
+
+func (c *UserRecoveryCode) Reset() {
+	c.Id = 0
+	c.UserId = 0
+	c.CodeDigest = c.CodeDigest[:0]
+	c.UsedAt = time.Time{}
 }
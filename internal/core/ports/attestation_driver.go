@@ -0,0 +1,107 @@
+package ports
+
+import "github.com/boris-army/server/internal/core/domain"
+
+//go:generate mockgen -source=$GOFILE -package=attestation -destination=../../impl/attestation/driver_mock.go
+
+type CommandAttestationSubmit struct {
+	UserId int64
+	Note   string
+	Result domain.UserAttestation
+}
+
+func (c *CommandAttestationSubmit) IsValid() bool {
+	return c.UserId > 0 && len(c.Note) <= 1024
+}
+
+func (c *CommandAttestationSubmit) Reset() {
+	c.UserId = 0
+	c.Note = ""
+	c.Result.Reset()
+}
+
+const (
+	AttestationListLimitDefault = 20
+	AttestationListLimitMax     = 100
+)
+
+type CommandAttestationListPending struct {
+	// Cursor is the NextCursor of the previous page, 0 for the first one.
+	Cursor int64
+	// Limit is the page size, AttestationListLimitDefault if 0.
+	Limit  int
+	Result struct {
+		Attestations []domain.UserAttestation
+		// NextCursor is 0 on the last page.
+		NextCursor int64
+	}
+}
+
+func (c *CommandAttestationListPending) IsValid() bool {
+	return c.Cursor >= 0 && c.Limit >= 0 && c.Limit <= AttestationListLimitMax
+}
+
+func (c *CommandAttestationListPending) Reset() {
+	c.Cursor = 0
+	c.Limit = 0
+	for i := range c.Result.Attestations {
+		c.Result.Attestations[i].Reset()
+	}
+	c.Result.Attestations = c.Result.Attestations[:0]
+	c.Result.NextCursor = 0
+}
+
+type CommandAttestationDecide struct {
+	Id         int64
+	ReviewerId int64
+	// Decision is one of domain.AttestationStatusApproved,
+	// domain.AttestationStatusRejected and domain.AttestationStatusRevoked.
+	Decision domain.AttestationStatus
+	// Reason is required unless approving.
+	Reason string
+	Result domain.UserAttestation
+}
+
+func (c *CommandAttestationDecide) IsValid() bool {
+	if c.Id < 1 || c.ReviewerId < 1 || len(c.Reason) > 1024 {
+		return false
+	}
+	switch c.Decision {
+	case domain.AttestationStatusApproved:
+		return true
+	case domain.AttestationStatusRejected, domain.AttestationStatusRevoked:
+		return len(c.Reason) > 0
+	default:
+		return false
+	}
+}
+
+func (c *CommandAttestationDecide) Reset() {
+	c.Id = 0
+	c.ReviewerId = 0
+	c.Decision = 0
+	c.Reason = ""
+	c.Result.Reset()
+}
+
+type DriverAttestation interface {
+	// Submit submits an attestation request of the user for review.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	domain.ErrExists - the user has a pending or approved attestation;
+	//	other - internal.
+	Submit(*CommandAttestationSubmit) error
+	// ListPending lists the attestations awaiting review, oldest first.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	other - internal.
+	ListPending(*CommandAttestationListPending) error
+	// Decide approves or rejects a pending attestation, or revokes an
+	// approved one. Approval grants domain.UserProofBoris to the user,
+	// revocation takes it away and terminates the user sessions.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	domain.ErrKey - no such attestation to decide on;
+	//	other - internal, the attestation may be revoked already.
+	Decide(*CommandAttestationDecide) error
+}
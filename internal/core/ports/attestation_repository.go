@@ -0,0 +1,26 @@
+package ports
+
+import "github.com/boris-army/server/internal/core/domain"
+
+//go:generate mockgen -source=$GOFILE -package=attestation -destination=../../impl/attestation/repository_mock.go
+
+type RepositoryAttestation interface {
+	// Create creates a pending attestation and replaces its Id and
+	// SubmittedAt.
+	// Errors:
+	//	domain.ErrExists - the user has a pending or approved attestation;
+	//	other - internal error.
+	Create(*domain.UserAttestation) error
+	// ListPending appends up to limit pending attestations with
+	// Id > afterId to dst, oldest first.
+	// Any error occurred must be interpreted as internal.
+	ListPending(dst []domain.UserAttestation, afterId int64, limit int) ([]domain.UserAttestation, error)
+	// Decide moves the attestation from the given status to a.Status,
+	// records a.ReviewerId and a.Reason, replaces a.UserId and a.DecidedAt
+	// and updates domain.UserProofBoris of the user accordingly.
+	// Reviewers can't decide on their own attestations.
+	// Errors:
+	//	domain.ErrKey - no such attestation in the given status;
+	//	other - internal error.
+	Decide(a *domain.UserAttestation, from domain.AttestationStatus) error
+}
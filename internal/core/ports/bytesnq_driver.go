@@ -1,12 +1,16 @@
 package ports
 
+//go:generate mockgen -source=$GOFILE -package=user -destination=../../impl/user/textnq_mock.go
+
 type DriverTextNSDeliverFn = func(recipient string, data []byte) error
 
-type DriverTextNSRenderFn = func(dst []byte) error
+// DriverTextNSRenderFn appends the message to dst and returns the result.
+type DriverTextNSRenderFn = func(dst []byte) ([]byte, error)
 
 type DriverTextNQ interface {
 	// Submit the text message for delivery. Returns whether the message
-	// will be processed shortly or not (depends on a queue load).
+	// will be processed shortly or not (depends on a queue load), an
+	// overloaded implementation may drop the message.
 	// Only reports render errors.
-	Submit(renderFn DriverTextNSRenderFn, deliverFn DriverTextNSDeliverFn) (shortly bool, errRender error)
+	Submit(recipient string, renderFn DriverTextNSRenderFn, deliverFn DriverTextNSDeliverFn) (shortly bool, errRender error)
 }
@@ -0,0 +1,34 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+//go:generate mockgen -source=$GOFILE -package=user -destination=../../impl/user/login_throttle_mock.go
+
+// RepositoryLoginThrottle keeps the failed login counters shared
+// by every server instance.
+type RepositoryLoginThrottle interface {
+	// FindLockedUntil returns the latest lock of the given keys,
+	// zero time if none is locked.
+	// Any error occurred must be interpreted as internal.
+	FindLockedUntil(keys ...string) (time.Time, error)
+	// RecordFailure counts a failure of the key at the given time and
+	// loads the updated throttle into dst. The counter starts over if
+	// the previous failure happened before windowStart.
+	// Any error occurred must be interpreted as internal.
+	RecordFailure(dst *domain.LoginThrottle, key string, at, windowStart time.Time) error
+	// Lock locks the key until the given time.
+	// Any error occurred must be interpreted as internal.
+	Lock(key string, until time.Time) error
+	// Reset forgets the failures of the key.
+	// Any error occurred must be interpreted as internal.
+	Reset(key string) error
+	// Purge deletes the throttles whose last failure happened before
+	// failedBefore and which aren't locked at the given time.
+	// Returns the number deleted.
+	// Any error occurred must be interpreted as internal.
+	Purge(failedBefore, now time.Time) (int, error)
+}
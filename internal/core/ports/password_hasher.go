@@ -0,0 +1,167 @@
+package ports
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/kzmnbrs/sly"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BCryptPasswordHasher accepts Argon2id digests in Verify, so going
+// back from Argon2idPasswordHasher doesn't lock anyone out.
+type BCryptPasswordHasher struct {
+	Cost int
+}
+
+func (h *BCryptPasswordHasher) Hash(password string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword(sly.S2B(password), h.Cost)
+}
+
+func (h *BCryptPasswordHasher) Verify(digest []byte, password string) (bool, error) {
+	if bytes.HasPrefix(digest, []byte(argon2idPrefix)) {
+		return (&Argon2idPasswordHasher{}).Verify(digest, password)
+	}
+
+	err := bcrypt.CompareHashAndPassword(digest, sly.S2B(password))
+	switch err {
+	case nil:
+		return true, nil
+	case bcrypt.ErrMismatchedHashAndPassword:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (h *BCryptPasswordHasher) NeedsRehash(digest []byte) bool {
+	cost, err := bcrypt.Cost(digest)
+	if err != nil {
+		// Not a bcrypt digest.
+		return true
+	}
+	return cost != h.Cost
+}
+
+// Argon2idPasswordHasher produces PHC formatted digests:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+//
+// Salt and key are base64 without padding. Verify accepts bcrypt
+// digests too, so the users hashed before switching keep logging in
+// and get rehashed along the way.
+type Argon2idPasswordHasher struct {
+	// Memory is in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLen     uint32
+	KeyLen      uint32
+}
+
+const argon2idPrefix = "$argon2id$"
+
+var errArgon2idDigest = errors.New("argon2id: malformed digest")
+
+func (h *Argon2idPasswordHasher) Hash(password string) ([]byte, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key := argon2.IDKey(sly.S2B(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLen)
+
+	b64 := base64.RawStdEncoding
+	dst := make([]byte, 0, 64+b64.EncodedLen(len(salt))+b64.EncodedLen(len(key)))
+	dst = append(dst, argon2idPrefix...)
+	dst = append(dst, "v="...)
+	dst = strconv.AppendInt(dst, argon2.Version, 10)
+	dst = append(dst, "$m="...)
+	dst = strconv.AppendUint(dst, uint64(h.Memory), 10)
+	dst = append(dst, ",t="...)
+	dst = strconv.AppendUint(dst, uint64(h.Iterations), 10)
+	dst = append(dst, ",p="...)
+	dst = strconv.AppendUint(dst, uint64(h.Parallelism), 10)
+	dst = append(dst, '$')
+	dst = appendBase64(dst, salt)
+	dst = append(dst, '$')
+	dst = appendBase64(dst, key)
+	return dst, nil
+}
+
+func (h *Argon2idPasswordHasher) Verify(digest []byte, password string) (bool, error) {
+	if !bytes.HasPrefix(digest, []byte(argon2idPrefix)) {
+		return (&BCryptPasswordHasher{}).Verify(digest, password)
+	}
+
+	var d argon2idDigest
+	if err := d.parse(digest); err != nil {
+		return false, err
+	}
+	key := argon2.IDKey(sly.S2B(password), d.salt, d.iterations, d.memory, d.parallelism, uint32(len(d.key)))
+	return subtle.ConstantTimeCompare(key, d.key) == 1, nil
+}
+
+func (h *Argon2idPasswordHasher) NeedsRehash(digest []byte) bool {
+	var d argon2idDigest
+	if err := d.parse(digest); err != nil {
+		return true
+	}
+	return d.memory != h.Memory ||
+		d.iterations != h.Iterations ||
+		d.parallelism != h.Parallelism ||
+		uint32(len(d.salt)) != h.SaltLen ||
+		uint32(len(d.key)) != h.KeyLen
+}
+
+type argon2idDigest struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (d *argon2idDigest) parse(digest []byte) error {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := bytes.Split(digest, []byte{'$'})
+	if len(parts) != 6 || len(parts[0]) != 0 || string(parts[1]) != "argon2id" {
+		return errArgon2idDigest
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(string(parts[2]), "v=%d", &version); err != nil {
+		return errArgon2idDigest
+	}
+	if version != argon2.Version {
+		return errArgon2idDigest
+	}
+	if _, err := fmt.Sscanf(string(parts[3]), "m=%d,t=%d,p=%d", &d.memory, &d.iterations, &d.parallelism); err != nil {
+		return errArgon2idDigest
+	}
+	if d.iterations < 1 || d.parallelism < 1 {
+		// argon2.IDKey panics on these.
+		return errArgon2idDigest
+	}
+
+	var err error
+	if d.salt, err = base64.RawStdEncoding.DecodeString(string(parts[4])); err != nil {
+		return errArgon2idDigest
+	}
+	if d.key, err = base64.RawStdEncoding.DecodeString(string(parts[5])); err != nil || len(d.key) == 0 {
+		return errArgon2idDigest
+	}
+	return nil
+}
+
+func appendBase64(dst, src []byte) []byte {
+	n := len(dst)
+	dst = append(dst, make([]byte, base64.RawStdEncoding.EncodedLen(len(src)))...)
+	base64.RawStdEncoding.Encode(dst[n:], src)
+	return dst
+}
@@ -0,0 +1,96 @@
+package ports
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func testArgon2idHasher() *Argon2idPasswordHasher {
+	return &Argon2idPasswordHasher{
+		Memory:      64,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLen:     16,
+		KeyLen:      32,
+	}
+}
+
+func TestArgon2idPasswordHasher_HashVerify(t *testing.T) {
+	h := testArgon2idHasher()
+
+	digest, err := h.Hash("qwerty123")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(digest), "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	ok, err := h.Verify(digest, "qwerty123")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = h.Verify(digest, "qwerty124")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	other, err := h.Hash("qwerty123")
+	assert.Nil(t, err)
+	assert.NotEqual(t, digest, other)
+}
+
+func TestArgon2idPasswordHasher_VerifyBCrypt(t *testing.T) {
+	h := testArgon2idHasher()
+
+	digest, err := bcrypt.GenerateFromPassword([]byte("qwerty123"), bcrypt.MinCost)
+	assert.Nil(t, err)
+
+	ok, err := h.Verify(digest, "qwerty123")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.True(t, h.NeedsRehash(digest))
+}
+
+func TestArgon2idPasswordHasher_Malformed(t *testing.T) {
+	h := testArgon2idHasher()
+
+	for _, digest := range []string{
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA",
+		"$argon2id$v=18$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$!!",
+	} {
+		_, err := h.Verify([]byte(digest), "qwerty123")
+		assert.NotNil(t, err, digest)
+		assert.True(t, h.NeedsRehash([]byte(digest)), digest)
+	}
+}
+
+func TestArgon2idPasswordHasher_NeedsRehash(t *testing.T) {
+	h := testArgon2idHasher()
+
+	digest, err := h.Hash("qwerty123")
+	assert.Nil(t, err)
+	assert.False(t, h.NeedsRehash(digest))
+
+	h.Iterations = 2
+	assert.True(t, h.NeedsRehash(digest))
+}
+
+func TestBCryptPasswordHasher_Argon2id(t *testing.T) {
+	h := &BCryptPasswordHasher{Cost: bcrypt.MinCost}
+
+	digest, err := h.Hash("qwerty123")
+	assert.Nil(t, err)
+	assert.False(t, h.NeedsRehash(digest))
+
+	h.Cost++
+	assert.True(t, h.NeedsRehash(digest))
+
+	argon2Digest, err := testArgon2idHasher().Hash("qwerty123")
+	assert.Nil(t, err)
+	assert.True(t, h.NeedsRehash(argon2Digest))
+
+	ok, err := h.Verify(argon2Digest, "qwerty123")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"crypto/sha256"
+
+	"github.com/kzmnbrs/sly"
+)
+
+// Sha256SecretDigester is enough for the secrets of 64+ random bits,
+// guessing them is as hard as reversing the digest.
+type Sha256SecretDigester struct{}
+
+func (Sha256SecretDigester) Digest(secret string) []byte {
+	digest := sha256.Sum256(sly.S2B(secret))
+	return digest[:]
+}
@@ -8,14 +8,25 @@ import (
 
 //go:generate mockgen -source=$GOFILE -package=session -destination=../../impl/session/driver_mock.go
 
+// JktLen is the length of a base64url SHA-256 JWK thumbprint.
+const JktLen = 43
+
 type CommandSessionHttpCreate struct {
 	UsedId    int64
 	UserEmail string
+	// UserRoles and UserProof are embedded into the token, the token
+	// scopes are granted by the roles.
+	UserRoles domain.UserRole
+	UserProof domain.UserProof
+	// Jkt binds the token to the client key of a DPoP proof,
+	// it is optional.
+	Jkt       string
 	IpAddr    net.IP
 	UserAgent string
 	Result    struct {
-		Session domain.Session
-		Token   domain.SessionHttpToken
+		Session  domain.Session
+		Token    domain.SessionHttpToken
+		TokenRaw []byte
 	}
 }
 
@@ -32,23 +43,76 @@ func (c *CommandSessionHttpCreate) IsValid() bool {
 	if len(c.UserAgent) == 0 || len(c.UserAgent) > 512 {
 		return false
 	}
+	if len(c.Jkt) != 0 && len(c.Jkt) != JktLen {
+		return false
+	}
 	return true
 }
 
 func (c *CommandSessionHttpCreate) Reset() {
 	c.UsedId = 0
 	c.UserEmail = ""
+	c.UserRoles = 0
+	c.UserProof = 0
+	c.Jkt = ""
 	c.IpAddr = nil
 	c.UserAgent = ""
 	c.Result.Session.Reset()
 	c.Result.Token.Reset()
+	c.Result.TokenRaw = c.Result.TokenRaw[:0]
+}
+
+const (
+	SessionListLimitDefault = 20
+	SessionListLimitMax     = 100
+)
+
+type CommandSessionListActive struct {
+	UserId int64
+	// Cursor is the NextCursor of the previous page, 0 for the first one.
+	Cursor int64
+	// Limit is the page size, SessionListLimitDefault if 0.
+	Limit  int
+	Result struct {
+		Sessions []domain.Session
+		// NextCursor is 0 on the last page.
+		NextCursor int64
+	}
+}
+
+func (c *CommandSessionListActive) IsValid() bool {
+	if c.UserId < 1 || c.Cursor < 0 {
+		return false
+	}
+	if c.Limit < 0 || c.Limit > SessionListLimitMax {
+		return false
+	}
+	return true
+}
+
+func (c *CommandSessionListActive) Reset() {
+	c.UserId = 0
+	c.Cursor = 0
+	c.Limit = 0
+	for i := range c.Result.Sessions {
+		c.Result.Sessions[i].Reset()
+	}
+	c.Result.Sessions = c.Result.Sessions[:0]
+	c.Result.NextCursor = 0
 }
 
 type DriverSession interface {
-	// CreateHttp create a new http session for the given user.
+	// CreateHttp create a new http session for the given user and
+	// encodes its access token to Result.TokenRaw.
 	// IpAddr and UserAgent must be derived from the http request.
-	// Any error occured must be considered internal.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	other - internal.
 	CreateHttp(create *CommandSessionHttpCreate) error
+	// EncodeHttpTokenTo appends the signed wire form of src to dst.
+	// The result is accepted by DecodeHttpTokenTo.
+	// Any error occured must be considered internal.
+	EncodeHttpTokenTo(dst []byte, src *domain.SessionHttpToken) ([]byte, error)
 	// DecodeHttpTokenTo decodes and validates the http session token.
 	// Errors:
 	//	domain.ErrValue - token can't be decoded or verified;
@@ -56,4 +120,22 @@ type DriverSession interface {
 	//	domain.ErrSessionTerminated - token had been revoked;
 	//	other - internal.
 	DecodeHttpTokenTo(dst *domain.SessionHttpToken, src []byte) error
+	// Terminate terminates the session, so its tokens are rejected
+	// by DecodeHttpTokenTo. Terminating twice is a no-op.
+	// Errors:
+	//	domain.ErrValue - invalid session id;
+	//	other - internal.
+	Terminate(sessionId int64) error
+	// TerminateAllForUser terminates every active session of the user
+	// except exceptSessionId, which may be 0 to terminate them all.
+	// Returns the number of sessions terminated.
+	// Errors:
+	//	domain.ErrValue - invalid user id;
+	//	other - internal.
+	TerminateAllForUser(userId, exceptSessionId int64) (int, error)
+	// ListActive lists the active sessions of the user, newest first.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	other - internal.
+	ListActive(*CommandSessionListActive) error
 }
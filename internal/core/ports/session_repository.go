@@ -12,4 +12,17 @@ type RepositorySession interface {
 	// The given buffer will be used to convert sessionId to bytes.
 	// Any error occurred must be interpreted as internal.
 	IsTerminated(sessionId int64, buf []byte) (bool, error)
+	// Terminate marks the session terminated. Terminating an already
+	// terminated session is a no-op.
+	// Any error occurred must be interpreted as internal.
+	Terminate(sessionId int64) error
+	// TerminateAllForUser terminates every active session of the user
+	// but exceptSessionId (0 to terminate all) and returns their count.
+	// Any error occurred must be interpreted as internal.
+	TerminateAllForUser(userId, exceptSessionId int64) (int, error)
+	// ListActiveForUser appends up to limit non-terminated, non-expired
+	// sessions of the user with Id < beforeId (any if 0) to dst,
+	// newest first.
+	// Any error occurred must be interpreted as internal.
+	ListActiveForUser(dst []domain.Session, userId, beforeId int64, limit int) ([]domain.Session, error)
 }
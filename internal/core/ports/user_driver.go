@@ -1,10 +1,10 @@
 package ports
 
 import (
+	"net"
 	"regexp"
-
-	"github.com/kzmnbrs/sly"
-	"golang.org/x/crypto/bcrypt"
+	"strconv"
+	"time"
 
 	"github.com/boris-army/server/internal/core/domain"
 )
@@ -35,10 +35,12 @@ func (c *CommandUserCreate) IsValid() bool {
 	if !givenNamesRe.MatchString(c.GivenNames) {
 		return false
 	}
-	if len(c.Password) < 6 || len(c.Password) > 32 {
-		return false
-	}
-	return true
+	return isValidPassword(c.Password)
+}
+
+// isValidPassword checks the rules for newly set passwords.
+func isValidPassword(password string) bool {
+	return len(password) >= 6 && len(password) <= 32
 }
 
 func (c *CommandUserCreate) Reset() {
@@ -48,20 +50,507 @@ func (c *CommandUserCreate) Reset() {
 	c.Result.Reset()
 }
 
+type CommandUserAuthenticate struct {
+	Email    string
+	Password string
+	// IpAddr is the client address, failed logins are counted
+	// per account and per address.
+	IpAddr net.IP
+	Result domain.User
+	// RetryAfter is set along with domain.ErrThrottled.
+	RetryAfter time.Duration
+	// Challenge is set along with domain.ErrSecondFactor. The login
+	// completes with VerifyLoginTotp.
+	Challenge struct {
+		Token     string
+		ExpiresAt time.Time
+	}
+}
+
+func (c *CommandUserAuthenticate) IsValid() bool {
+	if !userEmailRe.MatchString(c.Email) {
+		return false
+	}
+	if len(c.Password) == 0 || len(c.Password) > 72 {
+		return false
+	}
+	return true
+}
+
+func (c *CommandUserAuthenticate) Reset() {
+	c.Email = ""
+	c.Password = ""
+	c.IpAddr = nil
+	c.Result.Reset()
+	c.RetryAfter = 0
+	c.Challenge.Token = ""
+	c.Challenge.ExpiresAt = time.Time{}
+}
+
+type CommandUserEmailConfirmationResend struct {
+	Email string
+}
+
+func (c *CommandUserEmailConfirmationResend) IsValid() bool {
+	return userEmailRe.MatchString(c.Email)
+}
+
+func (c *CommandUserEmailConfirmationResend) Reset() {
+	c.Email = ""
+}
+
+type CommandUserEmailConfirm struct {
+	Token string
+}
+
+func (c *CommandUserEmailConfirm) IsValid() bool {
+	return len(c.Token) == SecretTokenLen
+}
+
+func (c *CommandUserEmailConfirm) Reset() {
+	c.Token = ""
+}
+
+type CommandUserPhoneAttach struct {
+	UserId int64
+	// Phone is an E.164 number, e.g. +79991234567.
+	Phone  string
+	Result struct {
+		// RetryAfter is set along with domain.ErrThrottled.
+		RetryAfter time.Duration
+	}
+}
+
+var phone164Re = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+func (c *CommandUserPhoneAttach) IsValid() bool {
+	if c.UserId < 1 {
+		return false
+	}
+	return phone164Re.MatchString(c.Phone)
+}
+
+// Phone164 returns Phone as a number. Valid only if IsValid.
+func (c *CommandUserPhoneAttach) Phone164() uint64 {
+	n, _ := strconv.ParseUint(c.Phone[1:], 10, 64)
+	return n
+}
+
+func (c *CommandUserPhoneAttach) Reset() {
+	c.UserId = 0
+	c.Phone = ""
+	c.Result.RetryAfter = 0
+}
+
+// PhoneCodeLen is the number of digits in phone verification codes.
+const PhoneCodeLen = 6
+
+type CommandUserPhoneVerify struct {
+	UserId int64
+	Code   string
+}
+
+func (c *CommandUserPhoneVerify) IsValid() bool {
+	return c.UserId > 0 && isValidCode(c.Code, PhoneCodeLen)
+}
+
+// isValidCode checks the one-time code is n decimal digits.
+func isValidCode(code string, n int) bool {
+	if len(code) != n {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < '0' || code[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *CommandUserPhoneVerify) Reset() {
+	c.UserId = 0
+	c.Code = ""
+}
+
+type CommandUserPasswordResetRequest struct {
+	Email string
+}
+
+func (c *CommandUserPasswordResetRequest) IsValid() bool {
+	return userEmailRe.MatchString(c.Email)
+}
+
+func (c *CommandUserPasswordResetRequest) Reset() {
+	c.Email = ""
+}
+
+type CommandUserPasswordReset struct {
+	Token    string
+	Password string
+}
+
+func (c *CommandUserPasswordReset) IsValid() bool {
+	if len(c.Token) != SecretTokenLen {
+		return false
+	}
+	return isValidPassword(c.Password)
+}
+
+func (c *CommandUserPasswordReset) Reset() {
+	c.Token = ""
+	c.Password = ""
+}
+
+type CommandUserPasswordChange struct {
+	UserId          int64
+	SessionId       int64
+	CurrentPassword string
+	NewPassword     string
+	// TerminateOthers terminates every session of the user
+	// except SessionId.
+	TerminateOthers bool
+	Result          struct {
+		Terminated int
+	}
+}
+
+func (c *CommandUserPasswordChange) IsValid() bool {
+	if c.UserId < 1 || c.SessionId < 1 {
+		return false
+	}
+	if len(c.CurrentPassword) == 0 || len(c.CurrentPassword) > 72 {
+		return false
+	}
+	return isValidPassword(c.NewPassword)
+}
+
+func (c *CommandUserPasswordChange) Reset() {
+	c.UserId = 0
+	c.SessionId = 0
+	c.CurrentPassword = ""
+	c.NewPassword = ""
+	c.TerminateOthers = false
+	c.Result.Terminated = 0
+}
+
+// TotpCodeLen is the number of digits in TOTP codes.
+const TotpCodeLen = 6
+
+// RecoveryCodeCount is the number of recovery codes in a set.
+const RecoveryCodeCount = 10
+
+// RecoveryCodeLen is the length of recovery codes: three groups of
+// five base32 characters, e.g. "abcde-fghij-klmn2".
+const RecoveryCodeLen = 17
+
+// IsRecoveryCode tells recovery codes from TOTP codes.
+func IsRecoveryCode(code string) bool {
+	if len(code) != RecoveryCodeLen {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if i == 5 || i == 11 {
+			if c != '-' {
+				return false
+			}
+			continue
+		}
+		if (c < 'a' || c > 'z') && (c < '2' || c > '7') {
+			return false
+		}
+	}
+	return true
+}
+
+type CommandUserTotpEnroll struct {
+	UserId int64
+	Result struct {
+		// Secret is base32 encoded for manual entry.
+		Secret string
+		// Uri is the otpauth:// URI, usually shown as a QR code.
+		Uri string
+		// RecoveryCodes are shown once, only their digests are kept.
+		RecoveryCodes []string
+	}
+}
+
+func (c *CommandUserTotpEnroll) IsValid() bool {
+	return c.UserId > 0
+}
+
+func (c *CommandUserTotpEnroll) Reset() {
+	c.UserId = 0
+	c.Result.Secret = ""
+	c.Result.Uri = ""
+	c.Result.RecoveryCodes = c.Result.RecoveryCodes[:0]
+}
+
+type CommandUserTotpConfirm struct {
+	UserId int64
+	Code   string
+}
+
+func (c *CommandUserTotpConfirm) IsValid() bool {
+	return c.UserId > 0 && isValidCode(c.Code, TotpCodeLen)
+}
+
+func (c *CommandUserTotpConfirm) Reset() {
+	c.UserId = 0
+	c.Code = ""
+}
+
+type CommandUserTotpDisable struct {
+	UserId   int64
+	Password string
+	// Code is a TOTP or recovery code.
+	Code string
+}
+
+func (c *CommandUserTotpDisable) IsValid() bool {
+	if c.UserId < 1 {
+		return false
+	}
+	if len(c.Password) == 0 || len(c.Password) > 72 {
+		return false
+	}
+	return isValidCode(c.Code, TotpCodeLen) || IsRecoveryCode(c.Code)
+}
+
+func (c *CommandUserTotpDisable) Reset() {
+	c.UserId = 0
+	c.Password = ""
+	c.Code = ""
+}
+
+type CommandUserLoginTotp struct {
+	// Challenge is the token from CommandUserAuthenticate.
+	Challenge string
+	// Code is a TOTP or recovery code.
+	Code string
+	// IpAddr is the client address, wrong codes are counted along
+	// with the failed logins.
+	IpAddr net.IP
+	Result domain.User
+	// RetryAfter is set along with domain.ErrThrottled.
+	RetryAfter time.Duration
+}
+
+func (c *CommandUserLoginTotp) IsValid() bool {
+	if len(c.Challenge) != SecretTokenLen {
+		return false
+	}
+	return isValidCode(c.Code, TotpCodeLen) || IsRecoveryCode(c.Code)
+}
+
+func (c *CommandUserLoginTotp) Reset() {
+	c.Challenge = ""
+	c.Code = ""
+	c.IpAddr = nil
+	c.Result.Reset()
+	c.RetryAfter = 0
+}
+
+type CommandUserRecoveryCodesRegenerate struct {
+	UserId   int64
+	Password string
+	// Code is a TOTP or recovery code.
+	Code   string
+	Result struct {
+		Codes []string
+	}
+}
+
+func (c *CommandUserRecoveryCodesRegenerate) IsValid() bool {
+	if c.UserId < 1 {
+		return false
+	}
+	if len(c.Password) == 0 || len(c.Password) > 72 {
+		return false
+	}
+	return isValidCode(c.Code, TotpCodeLen) || IsRecoveryCode(c.Code)
+}
+
+func (c *CommandUserRecoveryCodesRegenerate) Reset() {
+	c.UserId = 0
+	c.Password = ""
+	c.Code = ""
+	c.Result.Codes = c.Result.Codes[:0]
+}
+
+type CommandUserRecoveryCodesCount struct {
+	UserId int64
+	Result struct {
+		Remaining int
+	}
+}
+
+func (c *CommandUserRecoveryCodesCount) IsValid() bool {
+	return c.UserId > 0
+}
+
+func (c *CommandUserRecoveryCodesCount) Reset() {
+	c.UserId = 0
+	c.Result.Remaining = 0
+}
+
 type DriverUser interface {
-	// Create creates a new user from the given data.
+	// Create creates a new user from the given data and sends the email
+	// confirmation. A failure to send it is logged only, the user may
+	// ask for another with ResendEmailConfirmation.
 	// Errors:
 	//	domain.ErrExists - user exists;
+	//	domain.ErrOverloaded - no password hashing capacity left;
 	//	other - internal.
 	Create(*CommandUserCreate) error
+	// Authenticate looks the user up by email and verifies the password.
+	// Errors:
+	//	domain.ErrValue - malformed email or password;
+	//	domain.ErrCredentials - no such user or password mismatch;
+	//	domain.ErrSecondFactor - the password matches, the login must
+	//		be completed with VerifyLoginTotp;
+	//	domain.ErrThrottled - too many failed logins for the account
+	//		or the address;
+	//	domain.ErrOverloaded - no password hashing capacity left;
+	//	other - internal.
+	Authenticate(*CommandUserAuthenticate) error
+	// ResendEmailConfirmation issues a new confirmation token and
+	// delivers it to the user. Unknown, confirmed and expired emails are
+	// ignored, as well as the ones the previous token was sent to too
+	// recently: the result doesn't disclose whether the email is
+	// registered.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	other - internal.
+	ResendEmailConfirmation(*CommandUserEmailConfirmationResend) error
+	// ConfirmEmail sets domain.UserProofEmail for the token owner.
+	// Errors:
+	//	domain.ErrValue - malformed token;
+	//	domain.ErrKey - unknown or already used token;
+	//	domain.ErrExpired - the token has expired;
+	//	other - internal.
+	ConfirmEmail(*CommandUserEmailConfirm) error
+	// PurgeUnconfirmed deletes the users who failed to confirm their
+	// email in time. Returns the number of users deleted.
+	// Any error occured must be considered internal.
+	PurgeUnconfirmed() (int, error)
+	// PurgeLoginThrottles deletes the failed login counters which no
+	// longer affect logins. Returns the number of counters deleted.
+	// Any error occured must be considered internal.
+	PurgeLoginThrottles() (int, error)
+	// AttachPhone sends a verification code to the phone number.
+	// The number is stored once verified with VerifyPhone.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	domain.ErrThrottled - the previous code was sent too recently;
+	//	other - internal.
+	AttachPhone(*CommandUserPhoneAttach) error
+	// VerifyPhone checks the code sent by AttachPhone, stores the phone
+	// number and sets domain.UserProofPhone.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	domain.ErrKey - no code was sent;
+	//	domain.ErrExpired - the code has expired or ran out of attempts;
+	//	domain.ErrCredentials - wrong code;
+	//	other - internal.
+	VerifyPhone(*CommandUserPhoneVerify) error
+	// RequestPasswordReset issues a password reset token and delivers
+	// it to the user. Unknown emails are ignored, as well as the ones
+	// the previous token was sent to too recently: the result doesn't
+	// disclose whether the email is registered.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	other - internal.
+	RequestPasswordReset(*CommandUserPasswordResetRequest) error
+	// ResetPassword replaces the password of the token owner and
+	// terminates all of their sessions. A failed termination is
+	// logged, not returned, the password is replaced anyway.
+	// Errors:
+	//	domain.ErrValue - malformed token or invalid password;
+	//	domain.ErrKey - unknown or already used token;
+	//	domain.ErrExpired - the token has expired;
+	//	domain.ErrOverloaded - no password hashing capacity left;
+	//	other - internal.
+	ResetPassword(*CommandUserPasswordReset) error
+	// ChangePassword replaces the password of the user after checking
+	// the current one.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	domain.ErrKey - no such user;
+	//	domain.ErrCredentials - current password mismatch;
+	//	domain.ErrOverloaded - no password hashing capacity left;
+	//	other - internal.
+	ChangePassword(*CommandUserPasswordChange) error
+	// EnrollTotp generates a TOTP secret and a set of recovery codes
+	// for the user. 2FA is enabled once the secret is confirmed with
+	// ConfirmTotp.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	domain.ErrExists - 2FA is already enabled;
+	//	other - internal.
+	EnrollTotp(*CommandUserTotpEnroll) error
+	// ConfirmTotp checks the first code of the enrolled secret and
+	// enables 2FA.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	domain.ErrKey - no enrollment;
+	//	domain.ErrExists - 2FA is already enabled;
+	//	domain.ErrCredentials - wrong code;
+	//	other - internal.
+	ConfirmTotp(*CommandUserTotpConfirm) error
+	// DisableTotp disables 2FA after checking the password and a code,
+	// dropping the recovery codes.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	domain.ErrKey - 2FA is not enabled;
+	//	domain.ErrCredentials - wrong password or code;
+	//	other - internal.
+	DisableTotp(*CommandUserTotpDisable) error
+	// VerifyLoginTotp completes the login challenged by Authenticate.
+	// Each code is accepted once.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	domain.ErrKey - unknown challenge;
+	//	domain.ErrExpired - the challenge has expired or ran out
+	//		of attempts;
+	//	domain.ErrCredentials - wrong or used code;
+	//	domain.ErrThrottled - too many failed logins for the account
+	//		or the address;
+	//	other - internal.
+	VerifyLoginTotp(*CommandUserLoginTotp) error
+	// RegenerateRecoveryCodes replaces the recovery codes of the user
+	// after checking the password and a code.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	domain.ErrKey - 2FA is not enabled;
+	//	domain.ErrCredentials - wrong password or code;
+	//	domain.ErrOverloaded - no password hashing capacity left;
+	//	other - internal.
+	RegenerateRecoveryCodes(*CommandUserRecoveryCodesRegenerate) error
+	// CountRecoveryCodes counts the unused recovery codes of the user.
+	// Errors:
+	//	domain.ErrValue - invalid command;
+	//	other - internal.
+	CountRecoveryCodes(*CommandUserRecoveryCodesCount) error
 }
 
+// SecretTokenLen is the length of the single-use tokens sent to users:
+// 32 random bytes, base64url without padding.
+const SecretTokenLen = 43
+
 type PasswordHasher interface {
 	Hash(string) ([]byte, error)
+	// Verify reports whether the password matches the digest.
+	// Errors are only returned for malformed digests.
+	Verify(digest []byte, password string) (bool, error)
+	// NeedsRehash reports whether the digest was produced with other
+	// parameters or algorithm than Hash currently uses.
+	NeedsRehash(digest []byte) bool
 }
 
-type BCryptPasswordHasher struct{}
-
-func (h *BCryptPasswordHasher) Hash(password string) ([]byte, error) {
-	return bcrypt.GenerateFromPassword(sly.S2B(password), 14)
+// SecretDigester digests the random secrets stored for lookup, e.g.
+// the recovery codes. Unlike PasswordHasher it is deterministic and
+// unsalted, so a secret is found by its digest.
+type SecretDigester interface {
+	Digest(secret string) []byte
 }
@@ -1,6 +1,8 @@
 package ports
 
 import (
+	"time"
+
 	"github.com/boris-army/server/internal/core/domain"
 )
 
@@ -12,4 +14,130 @@ type RepositoryUser interface {
 	//	domain.ErrExists - the user already exists;
 	//	other - internal error.
 	Create(*domain.User) error
+	// FindByEmail loads the user with the given email into dst.
+	// Errors:
+	//	domain.ErrKey - no such user;
+	//	other - internal error.
+	FindByEmail(dst *domain.User, email string) error
+	// FindById loads the user with the given id into dst.
+	// Errors:
+	//	domain.ErrKey - no such user;
+	//	other - internal error.
+	FindById(dst *domain.User, id int64) error
+	// UpdatePasswordDigest replaces the password digest of the user.
+	// Errors:
+	//	domain.ErrKey - no such user;
+	//	other - internal error.
+	UpdatePasswordDigest(userId int64, passwordDigest []byte) error
+	// SaveEmailConfirmation creates or replaces the user confirmation.
+	// Any error occurred must be interpreted as internal.
+	SaveEmailConfirmation(*domain.UserEmailConfirmation) error
+	// FindEmailConfirmation loads the confirmation of the user into dst.
+	// Errors:
+	//	domain.ErrKey - no pending confirmation;
+	//	other - internal error.
+	FindEmailConfirmation(dst *domain.UserEmailConfirmation, userId int64) error
+	// ConfirmEmail consumes the confirmation with the given token digest
+	// and sets domain.UserProofEmail on its user.
+	// Errors:
+	//	domain.ErrKey - no such confirmation;
+	//	domain.ErrExpired - the confirmation has expired;
+	//	other - internal error.
+	ConfirmEmail(tokenDigest []byte) error
+	// PurgeUnconfirmed deletes the users created before the given time
+	// who have not confirmed their email. Returns the number deleted.
+	// Any error occurred must be interpreted as internal.
+	PurgeUnconfirmed(createdBefore time.Time) (int, error)
+	// SavePhoneVerification creates or replaces the user verification.
+	// Any error occurred must be interpreted as internal.
+	SavePhoneVerification(*domain.UserPhoneVerification) error
+	// FindPhoneVerification loads the verification of the user into dst.
+	// Errors:
+	//	domain.ErrKey - no pending verification;
+	//	other - internal error.
+	FindPhoneVerification(dst *domain.UserPhoneVerification, userId int64) error
+	// ConsumePhoneVerificationAttempt increments the attempt counter of
+	// the user verification and loads the updated verification into dst.
+	// Errors:
+	//	domain.ErrKey - no pending verification;
+	//	other - internal error.
+	ConsumePhoneVerificationAttempt(dst *domain.UserPhoneVerification, userId int64) error
+	// VerifyPhone consumes the user verification, stores its phone
+	// number and sets domain.UserProofPhone.
+	// Errors:
+	//	domain.ErrKey - no pending verification;
+	//	other - internal error.
+	VerifyPhone(userId int64) error
+	// SavePasswordReset creates or replaces the user password reset.
+	// Any error occurred must be interpreted as internal.
+	SavePasswordReset(*domain.UserPasswordReset) error
+	// FindPasswordReset loads the password reset of the user into dst.
+	// Errors:
+	//	domain.ErrKey - no pending reset;
+	//	other - internal error.
+	FindPasswordReset(dst *domain.UserPasswordReset, userId int64) error
+	// FindPasswordResetByToken loads the password reset with the given
+	// token digest into dst.
+	// Errors:
+	//	domain.ErrKey - no such reset;
+	//	other - internal error.
+	FindPasswordResetByToken(dst *domain.UserPasswordReset, tokenDigest []byte) error
+	// ResetPassword consumes the reset with the given token digest and
+	// replaces the password digest of its user. Returns the user id.
+	// Errors:
+	//	domain.ErrKey - no such reset;
+	//	domain.ErrExpired - the reset has expired;
+	//	other - internal error.
+	ResetPassword(tokenDigest, passwordDigest []byte) (int64, error)
+	// SaveTotpSecret stores the encrypted TOTP secret of the user with
+	// 2FA disabled, replacing a previous enrollment.
+	// Errors:
+	//	domain.ErrKey - no such user or 2FA is enabled;
+	//	other - internal error.
+	SaveTotpSecret(userId int64, secret []byte) error
+	// EnableTotp enables 2FA of the user with the stored secret and
+	// marks the step as used.
+	// Errors:
+	//	domain.ErrKey - no such user, no secret or 2FA is enabled;
+	//	other - internal error.
+	EnableTotp(userId, step int64) error
+	// DisableTotp disables 2FA of the user, drops the secret and the
+	// recovery codes.
+	// Any error occurred must be interpreted as internal.
+	DisableTotp(userId int64) error
+	// UseTotpStep marks the step as used if it is past the last one.
+	// Errors:
+	//	domain.ErrExpired - the step or a later one is used already;
+	//	other - internal error.
+	UseTotpStep(userId, step int64) error
+	// SaveLoginChallenge creates the challenge and deletes the expired
+	// ones of its user.
+	// Any error occurred must be interpreted as internal.
+	SaveLoginChallenge(*domain.UserLoginChallenge) error
+	// ConsumeLoginChallengeAttempt increments the attempt counter of
+	// the challenge and loads the updated challenge into dst.
+	// Errors:
+	//	domain.ErrKey - no such challenge;
+	//	other - internal error.
+	ConsumeLoginChallengeAttempt(dst *domain.UserLoginChallenge, tokenDigest []byte) error
+	// DeleteLoginChallenge deletes the challenge if it exists.
+	// Any error occurred must be interpreted as internal.
+	DeleteLoginChallenge(tokenDigest []byte) error
+	// ReplaceRecoveryCodes replaces the recovery codes of the user.
+	// Any error occurred must be interpreted as internal.
+	ReplaceRecoveryCodes(userId int64, codes []domain.UserRecoveryCode) error
+	// FindRecoveryCode loads the unused recovery code of the user with
+	// the given digest into dst.
+	// Errors:
+	//	domain.ErrKey - no such code;
+	//	other - internal error.
+	FindRecoveryCode(dst *domain.UserRecoveryCode, userId int64, codeDigest []byte) error
+	// UseRecoveryCode marks the recovery code as used.
+	// Errors:
+	//	domain.ErrExpired - the code is used already;
+	//	other - internal error.
+	UseRecoveryCode(id int64) error
+	// CountRecoveryCodes counts the unused recovery codes of the user.
+	// Any error occurred must be interpreted as internal.
+	CountRecoveryCodes(userId int64) (int, error)
 }
@@ -0,0 +1,78 @@
+package attestation
+
+import (
+	_ "github.com/golang/mock/mockgen/model"
+
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/core/ports"
+)
+
+type Driver struct {
+	Attestations ports.RepositoryAttestation
+	Sessions     ports.DriverSession
+}
+
+func (d *Driver) Submit(cmd *ports.CommandAttestationSubmit) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	a := &cmd.Result
+	a.UserId = cmd.UserId
+	a.Status = domain.AttestationStatusPending
+	a.Note = cmd.Note
+	return d.Attestations.Create(a)
+}
+
+func (d *Driver) ListPending(cmd *ports.CommandAttestationListPending) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	limit := cmd.Limit
+	if limit == 0 {
+		limit = ports.AttestationListLimitDefault
+	}
+
+	// One extra row tells whether there is a next page.
+	attestations, err := d.Attestations.ListPending(cmd.Result.Attestations[:0], cmd.Cursor, limit+1)
+	if err != nil {
+		return err
+	}
+
+	if len(attestations) > limit {
+		attestations = attestations[:limit]
+		cmd.Result.NextCursor = attestations[limit-1].Id
+	}
+	cmd.Result.Attestations = attestations
+	return nil
+}
+
+func (d *Driver) Decide(cmd *ports.CommandAttestationDecide) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	from := domain.AttestationStatusPending
+	if cmd.Decision == domain.AttestationStatusRevoked {
+		from = domain.AttestationStatusApproved
+	}
+
+	a := &cmd.Result
+	a.Id = cmd.Id
+	a.Status = cmd.Decision
+	a.ReviewerId = cmd.ReviewerId
+	a.Reason = cmd.Reason
+	if err := d.Attestations.Decide(a, from); err != nil {
+		return err
+	}
+
+	if a.Status == domain.AttestationStatusRevoked {
+		// The proof is copied into the session tokens, which are
+		// checked for termination on every use.
+		if _, err := d.Sessions.TerminateAllForUser(a.UserId, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
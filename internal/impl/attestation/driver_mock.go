@@ -0,0 +1,77 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: attestation_driver.go
+
+// Package attestation is a generated GoMock package.
+package attestation
+
+import (
+	reflect "reflect"
+
+	ports "github.com/boris-army/server/internal/core/ports"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDriverAttestation is a mock of DriverAttestation interface.
+type MockDriverAttestation struct {
+	ctrl     *gomock.Controller
+	recorder *MockDriverAttestationMockRecorder
+}
+
+// MockDriverAttestationMockRecorder is the mock recorder for MockDriverAttestation.
+type MockDriverAttestationMockRecorder struct {
+	mock *MockDriverAttestation
+}
+
+// NewMockDriverAttestation creates a new mock instance.
+func NewMockDriverAttestation(ctrl *gomock.Controller) *MockDriverAttestation {
+	mock := &MockDriverAttestation{ctrl: ctrl}
+	mock.recorder = &MockDriverAttestationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDriverAttestation) EXPECT() *MockDriverAttestationMockRecorder {
+	return m.recorder
+}
+
+// Decide mocks base method.
+func (m *MockDriverAttestation) Decide(arg0 *ports.CommandAttestationDecide) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decide", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Decide indicates an expected call of Decide.
+func (mr *MockDriverAttestationMockRecorder) Decide(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decide", reflect.TypeOf((*MockDriverAttestation)(nil).Decide), arg0)
+}
+
+// ListPending mocks base method.
+func (m *MockDriverAttestation) ListPending(arg0 *ports.CommandAttestationListPending) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPending", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListPending indicates an expected call of ListPending.
+func (mr *MockDriverAttestationMockRecorder) ListPending(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPending", reflect.TypeOf((*MockDriverAttestation)(nil).ListPending), arg0)
+}
+
+// Submit mocks base method.
+func (m *MockDriverAttestation) Submit(arg0 *ports.CommandAttestationSubmit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Submit", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Submit indicates an expected call of Submit.
+func (mr *MockDriverAttestationMockRecorder) Submit(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockDriverAttestation)(nil).Submit), arg0)
+}
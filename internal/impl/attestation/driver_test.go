@@ -0,0 +1,102 @@
+package attestation
+
+import (
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/core/ports"
+	"github.com/boris-army/server/internal/impl/session"
+)
+
+func TestDriver_Submit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := NewMockRepositoryAttestation(ctrl)
+	d := Driver{Attestations: repo}
+
+	repo.EXPECT().Create(&domain.UserAttestation{
+		UserId: 1,
+		Status: domain.AttestationStatusPending,
+		Note:   "see my passport",
+	}).Return(domain.ErrExists)
+
+	cmd := ports.CommandAttestationSubmit{UserId: 1, Note: "see my passport"}
+	assert.Equal(t, domain.ErrExists, d.Submit(&cmd))
+
+	assert.Equal(t, domain.ErrValue, d.Submit(&ports.CommandAttestationSubmit{}))
+}
+
+func TestDriver_ListPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := NewMockRepositoryAttestation(ctrl)
+	d := Driver{Attestations: repo}
+
+	repo.EXPECT().ListPending(gomock.Any(), int64(3), 3).
+		DoAndReturn(func(dst []domain.UserAttestation, _ int64, _ int) ([]domain.UserAttestation, error) {
+			return append(dst, domain.UserAttestation{Id: 4}, domain.UserAttestation{Id: 5}, domain.UserAttestation{Id: 6}), nil
+		})
+
+	cmd := ports.CommandAttestationListPending{Cursor: 3, Limit: 2}
+	assert.Equal(t, nil, d.ListPending(&cmd))
+	assert.Len(t, cmd.Result.Attestations, 2)
+	assert.Equal(t, int64(5), cmd.Result.NextCursor)
+}
+
+func TestDriver_Decide(t *testing.T) {
+	type tc struct {
+		name         string
+		decision     domain.AttestationStatus
+		reason       string
+		expFrom      domain.AttestationStatus
+		repoErr      error
+		expTerminate bool
+		terminateErr error
+		expErr       error
+	}
+	tcs := []tc{
+		{"approve", domain.AttestationStatusApproved, "", domain.AttestationStatusPending, nil, false, nil, nil},
+		{"reject", domain.AttestationStatusRejected, "blurry photo", domain.AttestationStatusPending, nil, false, nil, nil},
+		{"revoke", domain.AttestationStatusRevoked, "forged", domain.AttestationStatusApproved, nil, true, nil, nil},
+		{"revoke terminate failed", domain.AttestationStatusRevoked, "forged", domain.AttestationStatusApproved, nil, true, os.ErrNoDeadline, os.ErrNoDeadline},
+		{"not found", domain.AttestationStatusApproved, "", domain.AttestationStatusPending, domain.ErrKey, false, nil, domain.ErrKey},
+		{"internal", domain.AttestationStatusApproved, "", domain.AttestationStatusPending, os.ErrNoDeadline, false, nil, os.ErrNoDeadline},
+		{"reject without reason", domain.AttestationStatusRejected, "", 0, nil, false, nil, domain.ErrValue},
+		{"unknown decision", domain.AttestationStatusPending, "", 0, nil, false, nil, domain.ErrValue},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := NewMockRepositoryAttestation(ctrl)
+			sessDriver := session.NewMockDriverSession(ctrl)
+			d := Driver{Attestations: repo, Sessions: sessDriver}
+
+			if tc.expFrom != 0 {
+				repo.EXPECT().Decide(&domain.UserAttestation{
+					Id:         1,
+					Status:     tc.decision,
+					ReviewerId: 2,
+					Reason:     tc.reason,
+				}, tc.expFrom).DoAndReturn(func(a *domain.UserAttestation, _ domain.AttestationStatus) error {
+					a.UserId = 3
+					return tc.repoErr
+				})
+			}
+			if tc.expTerminate {
+				sessDriver.EXPECT().TerminateAllForUser(int64(3), int64(0)).Return(2, tc.terminateErr)
+			}
+
+			cmd := ports.CommandAttestationDecide{Id: 1, ReviewerId: 2, Decision: tc.decision, Reason: tc.reason}
+			assert.Equal(t, tc.expErr, d.Decide(&cmd))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: attestation_repository.go
+
+// Package attestation is a generated GoMock package.
+package attestation
+
+import (
+	reflect "reflect"
+
+	domain "github.com/boris-army/server/internal/core/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRepositoryAttestation is a mock of RepositoryAttestation interface.
+type MockRepositoryAttestation struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryAttestationMockRecorder
+}
+
+// MockRepositoryAttestationMockRecorder is the mock recorder for MockRepositoryAttestation.
+type MockRepositoryAttestationMockRecorder struct {
+	mock *MockRepositoryAttestation
+}
+
+// NewMockRepositoryAttestation creates a new mock instance.
+func NewMockRepositoryAttestation(ctrl *gomock.Controller) *MockRepositoryAttestation {
+	mock := &MockRepositoryAttestation{ctrl: ctrl}
+	mock.recorder = &MockRepositoryAttestationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepositoryAttestation) EXPECT() *MockRepositoryAttestationMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockRepositoryAttestation) Create(arg0 *domain.UserAttestation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRepositoryAttestationMockRecorder) Create(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepositoryAttestation)(nil).Create), arg0)
+}
+
+// Decide mocks base method.
+func (m *MockRepositoryAttestation) Decide(a *domain.UserAttestation, from domain.AttestationStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decide", a, from)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Decide indicates an expected call of Decide.
+func (mr *MockRepositoryAttestationMockRecorder) Decide(a, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decide", reflect.TypeOf((*MockRepositoryAttestation)(nil).Decide), a, from)
+}
+
+// ListPending mocks base method.
+func (m *MockRepositoryAttestation) ListPending(dst []domain.UserAttestation, afterId int64, limit int) ([]domain.UserAttestation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPending", dst, afterId, limit)
+	ret0, _ := ret[0].([]domain.UserAttestation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPending indicates an expected call of ListPending.
+func (mr *MockRepositoryAttestationMockRecorder) ListPending(dst, afterId, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPending", reflect.TypeOf((*MockRepositoryAttestation)(nil).ListPending), dst, afterId, limit)
+}
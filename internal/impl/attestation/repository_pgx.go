@@ -0,0 +1,148 @@
+package attestation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+type PgxRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func (r *PgxRepository) Create(a *domain.UserAttestation) error {
+	if a == nil {
+		log.Println("RepositoryAttestation/pgx: nil attestation in Create")
+		return domain.ErrValue
+	}
+
+	conn, err := r.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	a.SubmittedAt = time.Now()
+
+	// A concurrent submit is caught by the unique index on pending rows.
+	const insertAttestation = `
+		insert into user_attestations (user_id, status, note, submitted_at)
+		select $1, $2, $3, $4
+		where not exists (
+			select 1 from user_attestations
+			where user_id = $1 and status in ($2, $5)
+		)
+		returning id
+	`
+	row := conn.QueryRow(
+		context.Background(), insertAttestation,
+		a.UserId,
+		domain.AttestationStatusPending,
+		a.Note,
+		a.SubmittedAt,
+		domain.AttestationStatusApproved,
+	)
+	if err := row.Scan(&a.Id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrExists
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			// https://www.postgresql.org/docs/14/errcodes-appendix.html
+			const uniqueViolation = "23505"
+			if pgErr.Code == uniqueViolation {
+				return domain.ErrExists
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *PgxRepository) ListPending(dst []domain.UserAttestation, afterId int64, limit int) ([]domain.UserAttestation, error) {
+	conn, err := r.Pool.Acquire(context.Background())
+	if err != nil {
+		return dst, err
+	}
+	defer conn.Release()
+
+	const selectAttestations = `
+		select id, user_id, status, note, submitted_at
+		from user_attestations
+		where status = $1 and id > $2
+		order by id
+		limit $3
+	`
+	rows, err := conn.Query(context.Background(), selectAttestations, domain.AttestationStatusPending, afterId, limit)
+	if err != nil {
+		return dst, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		dst = append(dst, domain.UserAttestation{})
+		a := &dst[len(dst)-1]
+		if err := rows.Scan(&a.Id, &a.UserId, &a.Status, &a.Note, &a.SubmittedAt); err != nil {
+			return dst[:len(dst)-1], err
+		}
+	}
+
+	return dst, rows.Err()
+}
+
+func (r *PgxRepository) Decide(a *domain.UserAttestation, from domain.AttestationStatus) error {
+	conn, err := r.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	a.DecidedAt = time.Now()
+
+	return conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		const updateAttestation = `
+			update user_attestations set
+				status = $3,
+				reviewer_id = $4,
+				reason = $5,
+				decided_at = $6
+			where id = $1 and status = $2 and user_id <> $4
+			returning user_id
+		`
+		row := tx.QueryRow(
+			context.Background(), updateAttestation,
+			a.Id,
+			from,
+			a.Status,
+			a.ReviewerId,
+			a.Reason,
+			a.DecidedAt,
+		)
+		if err := row.Scan(&a.UserId); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrKey
+			}
+			return err
+		}
+
+		var updateUser string
+		switch a.Status {
+		case domain.AttestationStatusApproved:
+			updateUser = `update users set has_proof = has_proof | $2 where id = $1`
+		case domain.AttestationStatusRevoked:
+			updateUser = `update users set has_proof = has_proof & ~$2 where id = $1`
+		default:
+			return nil
+		}
+		_, err := tx.Exec(context.Background(), updateUser, a.UserId, domain.UserProofBoris)
+		return err
+	})
+}
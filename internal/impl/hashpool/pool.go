@@ -0,0 +1,107 @@
+package hashpool
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/boris-army/server/internal/core/domain"
+	"github.com/boris-army/server/internal/core/ports"
+)
+
+// Pool is a ports.PasswordHasher running Hash and Verify of the
+// wrapped hasher on a fixed set of workers, so a burst of logins
+// or registrations can't occupy every CPU.
+// Calls fail with domain.ErrOverloaded if the queue is full or no
+// worker picks the call up within the wait deadline.
+type Pool struct {
+	Hasher ports.PasswordHasher
+
+	jobs chan *job
+	wait time.Duration
+}
+
+const (
+	jobPending int32 = iota
+	jobRunning
+	jobAbandoned
+)
+
+type job struct {
+	state int32
+	fn    func()
+	done  chan struct{}
+}
+
+func NewPool(hasher ports.PasswordHasher, workers, queueSize int, wait time.Duration) *Pool {
+	p := &Pool{
+		Hasher: hasher,
+		jobs:   make(chan *job, queueSize),
+		wait:   wait,
+	}
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *Pool) Hash(password string) ([]byte, error) {
+	var (
+		digest []byte
+		err    error
+	)
+	if errRun := p.run(func() { digest, err = p.Hasher.Hash(password) }); errRun != nil {
+		return nil, errRun
+	}
+	return digest, err
+}
+
+func (p *Pool) Verify(digest []byte, password string) (bool, error) {
+	var (
+		ok  bool
+		err error
+	)
+	if errRun := p.run(func() { ok, err = p.Hasher.Verify(digest, password) }); errRun != nil {
+		return false, errRun
+	}
+	return ok, err
+}
+
+// NeedsRehash is cheap and runs on the caller goroutine.
+func (p *Pool) NeedsRehash(digest []byte) bool {
+	return p.Hasher.NeedsRehash(digest)
+}
+
+func (p *Pool) run(fn func()) error {
+	j := &job{fn: fn, done: make(chan struct{})}
+	select {
+	case p.jobs <- j:
+	default:
+		return domain.ErrOverloaded
+	}
+
+	timer := time.NewTimer(p.wait)
+	defer timer.Stop()
+
+	select {
+	case <-j.done:
+		return nil
+	case <-timer.C:
+		if atomic.CompareAndSwapInt32(&j.state, jobPending, jobAbandoned) {
+			return domain.ErrOverloaded
+		}
+		// A worker has just picked the job up, the deadline
+		// only covers the wait in the queue.
+		<-j.done
+		return nil
+	}
+}
+
+func (p *Pool) work() {
+	for j := range p.jobs {
+		if !atomic.CompareAndSwapInt32(&j.state, jobPending, jobRunning) {
+			continue
+		}
+		j.fn()
+		close(j.done)
+	}
+}
@@ -0,0 +1,81 @@
+package hashpool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+// blockingHasher blocks every call until release is closed.
+type blockingHasher struct {
+	release chan struct{}
+	calls   int32
+}
+
+func (h *blockingHasher) Hash(password string) ([]byte, error) {
+	atomic.AddInt32(&h.calls, 1)
+	<-h.release
+	return []byte(password), nil
+}
+
+func (h *blockingHasher) Verify(digest []byte, password string) (bool, error) {
+	atomic.AddInt32(&h.calls, 1)
+	<-h.release
+	return string(digest) == password, nil
+}
+
+func (h *blockingHasher) NeedsRehash([]byte) bool {
+	return false
+}
+
+func TestPool_Ok(t *testing.T) {
+	h := &blockingHasher{release: make(chan struct{})}
+	close(h.release)
+	p := NewPool(h, 2, 2, time.Second)
+
+	digest, err := p.Hash("qwerty123")
+	assert.Nil(t, err)
+	assert.Equal(t, "qwerty123", string(digest))
+
+	ok, err := p.Verify(digest, "qwerty123")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func TestPool_QueueFull(t *testing.T) {
+	h := &blockingHasher{release: make(chan struct{})}
+	defer close(h.release)
+	p := NewPool(h, 1, 1, time.Second)
+
+	// One call occupies the worker, another one the queue.
+	go func() { _, _ = p.Hash("a") }()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&h.calls) == 1 }, time.Second, time.Millisecond)
+	go func() { _, _ = p.Hash("b") }()
+	assert.Eventually(t, func() bool { return len(p.jobs) == 1 }, time.Second, time.Millisecond)
+
+	_, err := p.Hash("c")
+	assert.Equal(t, domain.ErrOverloaded, err)
+}
+
+func TestPool_WaitDeadline(t *testing.T) {
+	h := &blockingHasher{release: make(chan struct{})}
+	p := NewPool(h, 1, 1, time.Millisecond*10)
+
+	go func() { _, _ = p.Hash("a") }()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&h.calls) == 1 }, time.Second, time.Millisecond)
+
+	_, err := p.Verify([]byte("b"), "b")
+	assert.Equal(t, domain.ErrOverloaded, err)
+
+	// The abandoned call is skipped once the worker is free.
+	close(h.release)
+	assert.Eventually(t, func() bool { return len(p.jobs) == 0 }, time.Second, time.Millisecond)
+	digest, err := p.Hash("c")
+	assert.Nil(t, err)
+	assert.Equal(t, "c", string(digest))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&h.calls))
+}
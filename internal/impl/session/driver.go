@@ -34,10 +34,32 @@ func (d *Driver) CreateHttp(cmd *ports.CommandSessionHttpCreate) error {
 	tok.SessionId = sess.Id
 	tok.User.Id = sess.UserId
 	tok.User.Email = cmd.UserEmail
+	tok.User.Roles = cmd.UserRoles
+	tok.User.HasProof = cmd.UserProof
+	tok.Scopes = domain.UserRoleScopes(tok.Scopes[:0], cmd.UserRoles)
+	tok.Jkt = cmd.Jkt
 	tok.ExpiresAt = sess.ExpiresAt.Unix()
+
+	tokRaw, err := d.EncodeHttpTokenTo(cmd.Result.TokenRaw[:0], tok)
+	if err != nil {
+		return err
+	}
+	cmd.Result.TokenRaw = tokRaw
 	return nil
 }
 
+func (d *Driver) EncodeHttpTokenTo(dst []byte, src *domain.SessionHttpToken) ([]byte, error) {
+	tokData, err := easyjson.Marshal(src)
+	if err != nil {
+		return dst, err
+	}
+
+	actknCtx := actkn.AcquireCtx()
+	defer actkn.ReleaseCtx(actknCtx)
+
+	return d.Actkn.Encode(dst, tokData, actknCtx), nil
+}
+
 func (d *Driver) DecodeHttpTokenTo(dst *domain.SessionHttpToken, src []byte) error {
 	tokData := d.Actkn.Decode(src, &dst.Ctx)
 	if err := easyjson.Unmarshal(tokData, dst); err != nil {
@@ -58,3 +80,41 @@ func (d *Driver) DecodeHttpTokenTo(dst *domain.SessionHttpToken, src []byte) err
 
 	return nil
 }
+
+func (d *Driver) Terminate(sessionId int64) error {
+	if sessionId < 1 {
+		return domain.ErrValue
+	}
+	return d.Sessions.Terminate(sessionId)
+}
+
+func (d *Driver) TerminateAllForUser(userId, exceptSessionId int64) (int, error) {
+	if userId < 1 || exceptSessionId < 0 {
+		return 0, domain.ErrValue
+	}
+	return d.Sessions.TerminateAllForUser(userId, exceptSessionId)
+}
+
+func (d *Driver) ListActive(cmd *ports.CommandSessionListActive) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	limit := cmd.Limit
+	if limit == 0 {
+		limit = ports.SessionListLimitDefault
+	}
+
+	// One extra row tells whether there is a next page.
+	sessions, err := d.Sessions.ListActiveForUser(cmd.Result.Sessions[:0], cmd.UserId, cmd.Cursor, limit+1)
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) > limit {
+		sessions = sessions[:limit]
+		cmd.Result.NextCursor = sessions[limit-1].Id
+	}
+	cmd.Result.Sessions = sessions
+	return nil
+}
@@ -62,3 +62,61 @@ func (mr *MockDriverSessionMockRecorder) DecodeHttpTokenTo(dst, src interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecodeHttpTokenTo", reflect.TypeOf((*MockDriverSession)(nil).DecodeHttpTokenTo), dst, src)
 }
+
+// EncodeHttpTokenTo mocks base method.
+func (m *MockDriverSession) EncodeHttpTokenTo(dst []byte, src *domain.SessionHttpToken) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EncodeHttpTokenTo", dst, src)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EncodeHttpTokenTo indicates an expected call of EncodeHttpTokenTo.
+func (mr *MockDriverSessionMockRecorder) EncodeHttpTokenTo(dst, src interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EncodeHttpTokenTo", reflect.TypeOf((*MockDriverSession)(nil).EncodeHttpTokenTo), dst, src)
+}
+
+// ListActive mocks base method.
+func (m *MockDriverSession) ListActive(arg0 *ports.CommandSessionListActive) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActive", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListActive indicates an expected call of ListActive.
+func (mr *MockDriverSessionMockRecorder) ListActive(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActive", reflect.TypeOf((*MockDriverSession)(nil).ListActive), arg0)
+}
+
+// Terminate mocks base method.
+func (m *MockDriverSession) Terminate(sessionId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Terminate", sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Terminate indicates an expected call of Terminate.
+func (mr *MockDriverSessionMockRecorder) Terminate(sessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Terminate", reflect.TypeOf((*MockDriverSession)(nil).Terminate), sessionId)
+}
+
+// TerminateAllForUser mocks base method.
+func (m *MockDriverSession) TerminateAllForUser(userId, exceptSessionId int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TerminateAllForUser", userId, exceptSessionId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TerminateAllForUser indicates an expected call of TerminateAllForUser.
+func (mr *MockDriverSessionMockRecorder) TerminateAllForUser(userId, exceptSessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TerminateAllForUser", reflect.TypeOf((*MockDriverSession)(nil).TerminateAllForUser), userId, exceptSessionId)
+}
@@ -23,6 +23,8 @@ func TestDriver_CreateHttp_Ok(t *testing.T) {
 	cmd := ports.CommandSessionHttpCreate{
 		UsedId:    1,
 		UserEmail: "pgarin@old.me",
+		UserRoles: domain.UserRoleReviewer,
+		UserProof: domain.UserProofEmail,
 		IpAddr:    net.IP{127, 0, 0, 1},
 		UserAgent: "HTTPie",
 	}
@@ -38,9 +40,18 @@ func TestDriver_CreateHttp_Ok(t *testing.T) {
 		},
 	}).Return(nil)
 
-	d := Driver{Sessions: repoSess}
+	mgr := actkn.NewMockManager(ctrl)
+	mgr.EXPECT().Encode(gomock.Any(), gomock.Any(), gomock.Any()).Return([]byte("tok"))
+
+	d := Driver{Sessions: repoSess, Actkn: mgr}
 
 	assert.Equal(t, nil, d.CreateHttp(&cmd))
+	assert.Equal(t, []byte("tok"), cmd.Result.TokenRaw)
+
+	tok := &cmd.Result.Token
+	assert.Equal(t, domain.UserRoleReviewer, tok.User.Roles)
+	assert.Equal(t, domain.UserProofEmail, tok.User.HasProof)
+	assert.Equal(t, []string{domain.ScopeAccount, domain.ScopeAttestationsReview}, tok.Scopes)
 }
 
 func TestDriver_CreateHttp_InvalidCommand(t *testing.T) {
@@ -218,3 +229,136 @@ func TestDriver_DecodeHttpTokenTo_RevokeCheckFail(t *testing.T) {
 	tok2 := &domain.SessionHttpToken{}
 	assert.Equal(t, io.ErrShortWrite, d.DecodeHttpTokenTo(tok2, tokBs))
 }
+
+func TestDriver_EncodeHttpTokenTo_RoundTrip(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sess := NewMockRepositorySession(ctrl)
+	sess.EXPECT().IsTerminated(int64(1), gomock.Any()).Return(false, nil)
+
+	d := &Driver{
+		Sessions: sess,
+		Actkn:    actkn.NewManager("biWS2fEqV80PErLR6P-adQFhPhgfCM4zKS8hCpI0Pao"),
+	}
+
+	tok := domain.SessionHttpToken{
+		SessionId: 1,
+		User: domain.SessionHttpTokenUser{
+			Id:    1,
+			Email: "pgarin@old.me",
+		},
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}
+	tokRaw, err := d.EncodeHttpTokenTo(nil, &tok)
+	assert.Nil(t, err)
+
+	tok2 := domain.AcquireSessionHttpToken()
+	defer domain.ReleaseSessionHttpToken(tok2)
+
+	assert.Equal(t, nil, d.DecodeHttpTokenTo(tok2, tokRaw))
+	assert.Equal(t, tok.SessionId, tok2.SessionId)
+	assert.Equal(t, tok.User, tok2.User)
+	assert.Equal(t, tok.ExpiresAt, tok2.ExpiresAt)
+}
+
+func TestDriver_EncodeHttpTokenTo_Tampered(t *testing.T) {
+	d := &Driver{Actkn: actkn.NewManager("biWS2fEqV80PErLR6P-adQFhPhgfCM4zKS8hCpI0Pao")}
+
+	tok := domain.SessionHttpToken{SessionId: 1, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	tokRaw, err := d.EncodeHttpTokenTo(nil, &tok)
+	assert.Nil(t, err)
+	tokRaw[0] ^= 1
+
+	tok2 := domain.AcquireSessionHttpToken()
+	defer domain.ReleaseSessionHttpToken(tok2)
+
+	assert.Equal(t, domain.ErrValue, d.DecodeHttpTokenTo(tok2, tokRaw))
+}
+
+func TestDriver_Terminate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sess := NewMockRepositorySession(ctrl)
+	sess.EXPECT().Terminate(int64(1)).Return(nil)
+	sess.EXPECT().Terminate(int64(2)).Return(io.ErrShortWrite)
+
+	d := &Driver{Sessions: sess}
+	assert.Equal(t, nil, d.Terminate(1))
+	assert.Equal(t, io.ErrShortWrite, d.Terminate(2))
+	assert.Equal(t, domain.ErrValue, d.Terminate(0))
+}
+
+func TestDriver_TerminateAllForUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sess := NewMockRepositorySession(ctrl)
+	sess.EXPECT().TerminateAllForUser(int64(1), int64(2)).Return(3, nil)
+	sess.EXPECT().TerminateAllForUser(int64(1), int64(0)).Return(0, io.ErrShortWrite)
+
+	d := &Driver{Sessions: sess}
+
+	n, err := d.TerminateAllForUser(1, 2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, n)
+
+	_, err = d.TerminateAllForUser(1, 0)
+	assert.Equal(t, io.ErrShortWrite, err)
+
+	_, err = d.TerminateAllForUser(0, 0)
+	assert.Equal(t, domain.ErrValue, err)
+}
+
+func TestDriver_ListActive(t *testing.T) {
+	type tc struct {
+		name          string
+		limit         int
+		repoLimit     int
+		repoIds       []int64
+		expIds        []int64
+		expNextCursor int64
+	}
+	tcs := []tc{
+		{"default limit", 0, ports.SessionListLimitDefault + 1, []int64{9, 8}, []int64{9, 8}, 0},
+		{"last page", 2, 3, []int64{9, 8}, []int64{9, 8}, 0},
+		{"has next page", 2, 3, []int64{9, 8, 7}, []int64{9, 8}, 8},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sess := NewMockRepositorySession(ctrl)
+			sess.EXPECT().ListActiveForUser(gomock.Any(), int64(1), int64(10), tc.repoLimit).
+				DoAndReturn(func(dst []domain.Session, _, _ int64, _ int) ([]domain.Session, error) {
+					for _, id := range tc.repoIds {
+						dst = append(dst, domain.Session{Id: id})
+					}
+					return dst, nil
+				})
+
+			d := &Driver{Sessions: sess}
+
+			cmd := ports.CommandSessionListActive{UserId: 1, Cursor: 10, Limit: tc.limit}
+			assert.Equal(t, nil, d.ListActive(&cmd))
+
+			var ids []int64
+			for _, s := range cmd.Result.Sessions {
+				ids = append(ids, s.Id)
+			}
+			assert.Equal(t, tc.expIds, ids)
+			assert.Equal(t, tc.expNextCursor, cmd.Result.NextCursor)
+		})
+	}
+}
+
+func TestDriver_ListActive_InvalidCommand(t *testing.T) {
+	d := &Driver{}
+
+	cmd := ports.CommandSessionListActive{UserId: 1, Limit: ports.SessionListLimitMax + 1}
+	assert.False(t, cmd.IsValid())
+	assert.Equal(t, domain.ErrValue, d.ListActive(&cmd))
+}
@@ -62,3 +62,47 @@ func (mr *MockRepositorySessionMockRecorder) IsTerminated(sessionId, buf interfa
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTerminated", reflect.TypeOf((*MockRepositorySession)(nil).IsTerminated), sessionId, buf)
 }
+
+// ListActiveForUser mocks base method.
+func (m *MockRepositorySession) ListActiveForUser(dst []domain.Session, userId, beforeId int64, limit int) ([]domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActiveForUser", dst, userId, beforeId, limit)
+	ret0, _ := ret[0].([]domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveForUser indicates an expected call of ListActiveForUser.
+func (mr *MockRepositorySessionMockRecorder) ListActiveForUser(dst, userId, beforeId, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveForUser", reflect.TypeOf((*MockRepositorySession)(nil).ListActiveForUser), dst, userId, beforeId, limit)
+}
+
+// Terminate mocks base method.
+func (m *MockRepositorySession) Terminate(sessionId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Terminate", sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Terminate indicates an expected call of Terminate.
+func (mr *MockRepositorySessionMockRecorder) Terminate(sessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Terminate", reflect.TypeOf((*MockRepositorySession)(nil).Terminate), sessionId)
+}
+
+// TerminateAllForUser mocks base method.
+func (m *MockRepositorySession) TerminateAllForUser(userId, exceptSessionId int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TerminateAllForUser", userId, exceptSessionId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TerminateAllForUser indicates an expected call of TerminateAllForUser.
+func (mr *MockRepositorySessionMockRecorder) TerminateAllForUser(userId, exceptSessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TerminateAllForUser", reflect.TypeOf((*MockRepositorySession)(nil).TerminateAllForUser), userId, exceptSessionId)
+}
@@ -4,28 +4,43 @@ import (
 	"context"
 	"database/sql"
 	"log"
+	"math"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 	boom "github.com/tylertreat/BoomFilters"
 
+	"github.com/boris-army/server/internal/config"
 	"github.com/boris-army/server/internal/core/domain"
 )
 
 type PgxRepository struct {
-	Pool           *pgxpool.Pool
-	terminatedSids *boom.StableBloomFilter
+	Pool *pgxpool.Pool
+	conf *config.Session
+
+	// terminatedSidsMu guards terminatedSids, which is both rebuilt
+	// in background and updated by Terminate. The ids terminated
+	// during a rebuild go to nextTerminatedSids as well, the query
+	// of the rebuild may miss them.
+	terminatedSidsMu   sync.RWMutex
+	terminatedSids     *boom.StableBloomFilter
+	nextTerminatedSids *boom.StableBloomFilter
 }
 
-func NewPgxRepository(pool *pgxpool.Pool) (*PgxRepository, error) {
-	r := &PgxRepository{Pool: pool}
+// minTerminatedSidsCap keeps the filter usable on a database
+// without terminated sessions yet.
+const minTerminatedSidsCap = 1024
+
+func NewPgxRepository(pool *pgxpool.Pool, conf *config.Session) (*PgxRepository, error) {
+	r := &PgxRepository{Pool: pool, conf: conf}
 	if err := r.reindexTerminatedSids(); err != nil {
 		return nil, err
 	}
 	go func() {
 		for {
-			<-time.After(time.Minute * 3)
+			<-time.After(time.Duration(r.conf.TerminatedReindexPeriod))
 			if err := r.reindexTerminatedSids(); err != nil {
 				log.Println("RepositorySession/pgx: can't reindex terminated sessions:", err)
 			}
@@ -35,11 +50,58 @@ func NewPgxRepository(pool *pgxpool.Pool) (*PgxRepository, error) {
 }
 
 func (r *PgxRepository) Create(s *domain.Session) error {
-	return nil
+	if s == nil {
+		log.Println("RepositorySession/pgx: nil session in Create")
+		return domain.ErrValue
+	}
+
+	var ttl time.Duration
+	switch s.Type {
+	case domain.SessionTypeHttp:
+		ttl = time.Duration(r.conf.HttpTtl)
+	default:
+		log.Println("RepositorySession/pgx: unknown session type in Create:", s.Type)
+		return domain.ErrValue
+	}
+
+	conn, err := r.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	s.CreatedAt = time.Now()
+	s.ExpiresAt = s.CreatedAt.Add(ttl)
+
+	const insertSession = `
+		insert into sessions (
+			id,
+			user_id,
+			type,
+			ip_addr,
+			user_agent,
+			created_at,
+			expires_at
+		) values (default, $1, $2, $3, $4, $5, $6)
+		returning id
+	`
+	row := conn.QueryRow(
+		context.Background(), insertSession,
+		s.UserId,
+		s.Type,
+		s.Http.IpAddr,
+		s.Http.UserAgent,
+		s.CreatedAt,
+		s.ExpiresAt,
+	)
+
+	return row.Scan(&s.Id)
 }
 
 func (r *PgxRepository) IsTerminated(sid int64, buf []byte) (bool, error) {
+	r.terminatedSidsMu.RLock()
 	maybeTerminated := r.terminatedSids.Test(strconv.AppendInt(buf, sid, 10))
+	r.terminatedSidsMu.RUnlock()
 	if !maybeTerminated {
 		return false, nil
 	}
@@ -52,7 +114,7 @@ func (r *PgxRepository) IsTerminated(sid int64, buf []byte) (bool, error) {
 
 	const selectSession = `
 		select terminated_at from sessions
-		where id = ?
+		where id = $1
 	`
 	var terminatedAt sql.NullTime
 	if err := conn.QueryRow(context.Background(), selectSession, sid).Scan(&terminatedAt); err != nil {
@@ -66,33 +128,162 @@ func (r *PgxRepository) IsTerminated(sid int64, buf []byte) (bool, error) {
 	return false, nil
 }
 
-func (r *PgxRepository) reindexTerminatedSids() error {
+func (r *PgxRepository) Terminate(sid int64) error {
 	conn, err := r.Pool.Acquire(context.Background())
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	if r.terminatedSids == nil {
-		const selectTerminatedSidsCount = `
-			select count(*) from sessions
-			where terminated_at is not null
-		`
-		var count uint
-		if err := conn.QueryRow(context.Background(), selectTerminatedSidsCount).Scan(&count); err != nil {
-			return err
+	const updateSession = `
+		update sessions set terminated_at = now()
+		where id = $1 and terminated_at is null
+	`
+	if _, err := conn.Exec(context.Background(), updateSession, sid); err != nil {
+		return err
+	}
+
+	// Other instances will catch up on the next reindex.
+	r.addTerminatedSid(sid, nil)
+	return nil
+}
+
+func (r *PgxRepository) TerminateAllForUser(userId, exceptSid int64) (int, error) {
+	conn, err := r.Pool.Acquire(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	const updateSessions = `
+		update sessions set terminated_at = now()
+		where user_id = $1 and id <> $2 and terminated_at is null
+		returning id
+	`
+	rows, err := conn.Query(context.Background(), updateSessions, userId, exceptSid)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var (
+		sid   int64
+		buf   []byte
+		count int
+	)
+	for rows.Next() {
+		if err := rows.Scan(&sid); err != nil {
+			return count, err
 		}
-		switch {
-		case count > 0:
-			r.terminatedSids = boom.NewUnstableBloomFilter(uint(float64(count)*1.2), .0001)
-		default:
-			return nil
+		r.addTerminatedSid(sid, buf[:0])
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+func (r *PgxRepository) ListActiveForUser(dst []domain.Session, userId, beforeSid int64, limit int) ([]domain.Session, error) {
+	conn, err := r.Pool.Acquire(context.Background())
+	if err != nil {
+		return dst, err
+	}
+	defer conn.Release()
+
+	if beforeSid == 0 {
+		beforeSid = math.MaxInt64
+	}
+
+	const selectSessions = `
+		select
+			id,
+			user_id,
+			type,
+			ip_addr,
+			user_agent,
+			created_at,
+			expires_at
+		from sessions
+		where user_id = $1
+			and id < $2
+			and terminated_at is null
+			and expires_at > now()
+		order by id desc
+		limit $3
+	`
+	rows, err := conn.Query(context.Background(), selectSessions, userId, beforeSid, limit)
+	if err != nil {
+		return dst, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		dst = append(dst, domain.Session{})
+		s := &dst[len(dst)-1]
+		if err := rows.Scan(
+			&s.Id,
+			&s.UserId,
+			&s.Type,
+			&s.Http.IpAddr,
+			&s.Http.UserAgent,
+			&s.CreatedAt,
+			&s.ExpiresAt,
+		); err != nil {
+			return dst[:len(dst)-1], err
 		}
 	}
 
+	return dst, rows.Err()
+}
+
+func (r *PgxRepository) addTerminatedSid(sid int64, buf []byte) {
+	buf = strconv.AppendInt(buf, sid, 10)
+	r.terminatedSidsMu.Lock()
+	r.terminatedSids.Add(buf)
+	if r.nextTerminatedSids != nil {
+		r.nextTerminatedSids.Add(buf)
+	}
+	r.terminatedSidsMu.Unlock()
+}
+
+// reindexTerminatedSids rebuilds the filter from the sessions whose
+// tokens are yet to expire, so it doesn't fill up with the ones
+// nobody can present anymore. The filter is sized for their count.
+func (r *PgxRepository) reindexTerminatedSids() error {
+	conn, err := r.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const selectTerminatedSidsCount = `
+		select count(*) from sessions
+		where terminated_at is not null and expires_at > now()
+	`
+	var count uint
+	if err := conn.QueryRow(context.Background(), selectTerminatedSidsCount).Scan(&count); err != nil {
+		return err
+	}
+	capacity := uint(float64(count) * 1.2)
+	if capacity < minTerminatedSidsCap {
+		capacity = minTerminatedSidsCap
+	}
+	next := boom.NewUnstableBloomFilter(capacity, r.conf.TerminatedFalsePositiveRate)
+
+	r.terminatedSidsMu.Lock()
+	r.nextTerminatedSids = next
+	r.terminatedSidsMu.Unlock()
+	defer func() {
+		r.terminatedSidsMu.Lock()
+		r.nextTerminatedSids = nil
+		r.terminatedSidsMu.Unlock()
+	}()
+
 	const selectTerminatedSids = `
 		select id from sessions
-		where terminated_at is not null
+		where terminated_at is not null and expires_at > now()
 	`
 	rows, err := conn.Query(context.Background(), selectTerminatedSids)
 	if err != nil {
@@ -101,9 +292,9 @@ func (r *PgxRepository) reindexTerminatedSids() error {
 	defer rows.Close()
 
 	var (
-		sid   int64
-		buf   []byte
-		count uint
+		sid    int64
+		buf    []byte
+		loaded uint
 	)
 	for rows.Next() {
 		if err := rows.Scan(&sid); err != nil {
@@ -111,13 +302,20 @@ func (r *PgxRepository) reindexTerminatedSids() error {
 			continue
 		}
 
-		r.terminatedSids.Add(strconv.AppendInt(buf[:0], sid, 10))
-		count++
+		buf = strconv.AppendInt(buf[:0], sid, 10)
+		r.terminatedSidsMu.Lock()
+		next.Add(buf)
+		r.terminatedSidsMu.Unlock()
+		loaded++
 	}
 	if err := rows.Err(); err != nil {
 		return err
 	}
 
-	log.Println("RepositorySession/pgx: loaded", count, "terminated session ids")
+	r.terminatedSidsMu.Lock()
+	r.terminatedSids = next
+	r.terminatedSidsMu.Unlock()
+
+	log.Println("RepositorySession/pgx: loaded", loaded, "terminated session ids")
 	return nil
 }
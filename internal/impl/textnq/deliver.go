@@ -0,0 +1,79 @@
+package textnq
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/smtp"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Smtp delivers rendered messages by email. Rendered messages must
+// start with their own headers (e.g. Subject), From and To are added.
+type Smtp struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+func (s *Smtp) Deliver(recipient string, data []byte) error {
+	msg := make([]byte, 0, len(data)+len(s.From)+len(recipient)+16)
+	msg = append(msg, "From: "...)
+	msg = append(msg, s.From...)
+	msg = append(msg, "\r\nTo: "...)
+	msg = append(msg, recipient...)
+	msg = append(msg, "\r\n"...)
+	msg = append(msg, data...)
+	return smtp.SendMail(s.Addr, s.Auth, s.From, []string{recipient}, msg)
+}
+
+// SmsGateway delivers rendered messages as text messages by posting
+// {"to":"<recipient>","text":"<message>"} to an HTTP gateway.
+type SmsGateway struct {
+	Url     string
+	Token   string
+	Timeout time.Duration
+	Client  fasthttp.Client
+}
+
+func (g *SmsGateway) Deliver(recipient string, data []byte) error {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	res := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(res)
+
+	req.SetRequestURI(g.Url)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType("application/json")
+	if len(g.Token) > 0 {
+		req.Header.Set(fasthttp.HeaderAuthorization, "Bearer "+g.Token)
+	}
+
+	body, err := json.Marshal(smsGatewayMessage{To: recipient, Text: string(data)})
+	if err != nil {
+		return err
+	}
+	req.SetBodyRaw(body)
+
+	if err := g.Client.DoTimeout(req, res, g.Timeout); err != nil {
+		return err
+	}
+	if res.StatusCode() >= 300 {
+		return fmt.Errorf("sms gateway responded %d", res.StatusCode())
+	}
+	return nil
+}
+
+type smsGatewayMessage struct {
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+// LogDeliver prints messages instead of delivering them.
+// Meant for development only as messages carry secrets.
+func LogDeliver(recipient string, data []byte) error {
+	log.Printf("DriverTextNQ/log: message to %s:\n%s\n", recipient, data)
+	return nil
+}
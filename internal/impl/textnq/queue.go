@@ -0,0 +1,71 @@
+package textnq
+
+import (
+	"log"
+	"sync"
+
+	"github.com/boris-army/server/internal/core/ports"
+)
+
+// Queue is an in-process ports.DriverTextNQ. Messages are rendered
+// synchronously by Submit and delivered by a fixed set of workers.
+// Messages submitted to a full queue are dropped.
+type Queue struct {
+	jobs chan job
+}
+
+type job struct {
+	recipient string
+	buf       *[]byte
+	deliverFn ports.DriverTextNSDeliverFn
+}
+
+var bufPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, 1024)
+		return &buf
+	},
+}
+
+func NewQueue(size, workers int) *Queue {
+	q := &Queue{jobs: make(chan job, size)}
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+func (q *Queue) Submit(
+	recipient string,
+	renderFn ports.DriverTextNSRenderFn,
+	deliverFn ports.DriverTextNSDeliverFn,
+) (bool, error) {
+	buf := bufPool.Get().(*[]byte)
+	data, err := renderFn((*buf)[:0])
+	if err != nil {
+		bufPool.Put(buf)
+		return false, err
+	}
+	*buf = data
+
+	j := job{recipient: recipient, buf: buf, deliverFn: deliverFn}
+	select {
+	case q.jobs <- j:
+		return true, nil
+	default:
+		// The queue is full: the message is dropped rather than
+		// waiting for a free slot, the clients may ask for another.
+		log.Println("DriverTextNQ: queue is full, dropping message to", recipient)
+		bufPool.Put(buf)
+		return false, nil
+	}
+}
+
+func (q *Queue) work() {
+	for j := range q.jobs {
+		if err := j.deliverFn(j.recipient, *j.buf); err != nil {
+			log.Println("DriverTextNQ: can't deliver message to", j.recipient+":", err)
+		}
+		bufPool.Put(j.buf)
+	}
+}
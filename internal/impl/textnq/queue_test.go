@@ -0,0 +1,56 @@
+package textnq
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue_Submit(t *testing.T) {
+	q := NewQueue(1, 1)
+
+	type delivery struct {
+		recipient string
+		data      string
+	}
+	delivered := make(chan delivery, 1)
+
+	shortly, err := q.Submit("pgarin@old.me", func(dst []byte) ([]byte, error) {
+		return append(dst, "hello"...), nil
+	}, func(recipient string, data []byte) error {
+		delivered <- delivery{recipient, string(data)}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, shortly)
+
+	select {
+	case d := <-delivered:
+		assert.Equal(t, delivery{"pgarin@old.me", "hello"}, d)
+	case <-time.After(time.Second):
+		t.Fatal("message was not delivered")
+	}
+}
+
+func TestQueue_Submit_RenderErr(t *testing.T) {
+	q := NewQueue(1, 0)
+
+	shortly, err := q.Submit("pgarin@old.me", func(dst []byte) ([]byte, error) {
+		return dst, io.ErrShortWrite
+	}, nil)
+	assert.Equal(t, io.ErrShortWrite, err)
+	assert.False(t, shortly)
+}
+
+func TestQueue_Submit_Full(t *testing.T) {
+	q := NewQueue(0, 0)
+
+	shortly, err := q.Submit("pgarin@old.me", func(dst []byte) ([]byte, error) {
+		return dst, nil
+	}, nil)
+	assert.Nil(t, err)
+	assert.False(t, shortly)
+	assert.Len(t, q.jobs, 0, "dropped")
+}
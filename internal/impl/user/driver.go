@@ -1,8 +1,14 @@
 package user
 
 import (
+	"crypto/subtle"
+	"log"
+	"sync"
+	"time"
+
 	_ "github.com/golang/mock/mockgen/model"
 
+	"github.com/boris-army/server/internal/config"
 	"github.com/boris-army/server/internal/core/domain"
 	"github.com/boris-army/server/internal/core/ports"
 )
@@ -10,6 +16,16 @@ import (
 type Driver struct {
 	Users          ports.RepositoryUser
 	PasswordHasher ports.PasswordHasher
+	CodeDigester   ports.SecretDigester
+	Throttles      ports.RepositoryLoginThrottle
+	Sessions       ports.DriverSession
+	Texts          ports.DriverTextNQ
+	DeliverEmail   ports.DriverTextNSDeliverFn
+	DeliverSms     ports.DriverTextNSDeliverFn
+	Conf           *config.User
+
+	dummyMu     sync.Mutex
+	dummyDigest []byte
 }
 
 func (d *Driver) Create(cmd *ports.CommandUserCreate) error {
@@ -32,5 +48,643 @@ func (d *Driver) Create(cmd *ports.CommandUserCreate) error {
 		return err
 	}
 
+	// The user exists from now on, a retry would fail with
+	// domain.ErrExists. The confirmation can be resent instead.
+	if err := d.sendEmailConfirmation(u); err != nil {
+		log.Println("DriverUser: can't send email confirmation:", err)
+	}
+	return nil
+}
+
+func (d *Driver) Authenticate(cmd *ports.CommandUserAuthenticate) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	now := time.Now()
+	account, addr := loginThrottleKeys(cmd.Email, cmd.IpAddr)
+	if err := d.checkLoginThrottle(&cmd.RetryAfter, account, addr, now); err != nil {
+		return err
+	}
+
+	u := &cmd.Result
+	if err := d.Users.FindByEmail(u, cmd.Email); err != nil {
+		if err == domain.ErrKey {
+			if err := d.verifyDummy(cmd.Password); err != nil {
+				return err
+			}
+			return d.loginFailed(account, addr, now)
+		}
+		return err
+	}
+
+	ok, err := d.PasswordHasher.Verify(u.PasswordDigest, cmd.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return d.loginFailed(account, addr, now)
+	}
+
+	if d.PasswordHasher.NeedsRehash(u.PasswordDigest) {
+		d.rehashPassword(u, cmd.Password)
+	}
+
+	if u.TotpEnabled {
+		// The failures are kept until the second factor passes.
+		return d.challengeLogin(cmd)
+	}
+
+	d.resetLoginThrottle(account)
+	return nil
+}
+
+// verifyDummy spends as much time as verifying a password of a user,
+// so the response time doesn't disclose whether the email is registered.
+// The dummy digest is made by the current hasher, thus of the same cost.
+// The errors are those of the hasher, e.g. domain.ErrOverloaded, so
+// an overload doesn't disclose it either.
+func (d *Driver) verifyDummy(password string) error {
+	d.dummyMu.Lock()
+	if d.dummyDigest == nil {
+		digest, err := d.PasswordHasher.Hash("dummy password")
+		if err != nil {
+			d.dummyMu.Unlock()
+			return err
+		}
+		d.dummyDigest = digest
+	}
+	digest := d.dummyDigest
+	d.dummyMu.Unlock()
+
+	_, err := d.PasswordHasher.Verify(digest, password)
+	return err
+}
+
+// challengeLogin issues a token to complete the login of the
+// authenticated user with VerifyLoginTotp.
+func (d *Driver) challengeLogin(cmd *ports.CommandUserAuthenticate) error {
+	token, digest, err := newSecretToken()
+	if err != nil {
+		return err
+	}
+
+	c := domain.UserLoginChallenge{
+		TokenDigest: digest,
+		UserId:      cmd.Result.Id,
+		ExpiresAt:   time.Now().Add(time.Duration(d.Conf.LoginChallengeTtl)),
+	}
+	if err := d.Users.SaveLoginChallenge(&c); err != nil {
+		return err
+	}
+
+	cmd.Challenge.Token = token
+	cmd.Challenge.ExpiresAt = c.ExpiresAt
+	return domain.ErrSecondFactor
+}
+
+// rehashPassword upgrades the user password digest to the current
+// hashing policy. Failures are logged only, the login goes on with
+// the old digest.
+func (d *Driver) rehashPassword(u *domain.User, password string) {
+	passwordDigest, err := d.PasswordHasher.Hash(password)
+	if err != nil {
+		log.Println("DriverUser: can't rehash password:", err)
+		return
+	}
+	if err := d.Users.UpdatePasswordDigest(u.Id, passwordDigest); err != nil {
+		log.Println("DriverUser: can't update password digest:", err)
+		return
+	}
+	u.PasswordDigest = passwordDigest
+}
+
+func (d *Driver) ResendEmailConfirmation(cmd *ports.CommandUserEmailConfirmationResend) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	// Every refusal is silent to not disclose whether the email
+	// is registered.
+	var u domain.User
+	if err := d.Users.FindByEmail(&u, cmd.Email); err != nil {
+		if err == domain.ErrKey {
+			return nil
+		}
+		return err
+	}
+	if u.HasProof&domain.UserProofEmail != 0 {
+		return nil
+	}
+
+	now := time.Now()
+	if now.After(u.CreatedAt.Add(time.Duration(d.Conf.EmailConfirmTtl))) {
+		return nil
+	}
+
+	var c domain.UserEmailConfirmation
+	switch err := d.Users.FindEmailConfirmation(&c, u.Id); err {
+	case nil:
+		if now.Before(c.SentAt.Add(time.Duration(d.Conf.EmailResendInterval))) {
+			return nil
+		}
+	case domain.ErrKey:
+	default:
+		return err
+	}
+
+	return d.sendEmailConfirmation(&u)
+}
+
+func (d *Driver) ConfirmEmail(cmd *ports.CommandUserEmailConfirm) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+	return d.Users.ConfirmEmail(secretTokenDigest(cmd.Token))
+}
+
+func (d *Driver) PurgeUnconfirmed() (int, error) {
+	return d.Users.PurgeUnconfirmed(time.Now().Add(-time.Duration(d.Conf.EmailConfirmTtl)))
+}
+
+func (d *Driver) AttachPhone(cmd *ports.CommandUserPhoneAttach) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	now := time.Now()
+
+	var v domain.UserPhoneVerification
+	switch err := d.Users.FindPhoneVerification(&v, cmd.UserId); err {
+	case nil:
+		nextAt := v.SentAt.Add(time.Duration(d.Conf.PhoneResendInterval))
+		if now.Before(nextAt) {
+			cmd.Result.RetryAfter = nextAt.Sub(now)
+			return domain.ErrThrottled
+		}
+	case domain.ErrKey:
+	default:
+		return err
+	}
+
+	code, digest, err := newPhoneCode(cmd.UserId)
+	if err != nil {
+		return err
+	}
+
+	v.Reset()
+	v.UserId = cmd.UserId
+	v.Phone164 = cmd.Phone164()
+	v.CodeDigest = digest
+	v.SentAt = now
+	v.ExpiresAt = now.Add(time.Duration(d.Conf.PhoneCodeTtl))
+	if err := d.Users.SavePhoneVerification(&v); err != nil {
+		return err
+	}
+
+	_, err = d.Texts.Submit(cmd.Phone, func(dst []byte) ([]byte, error) {
+		dst = append(dst, "Boris verification code: "...)
+		dst = append(dst, code...)
+		return dst, nil
+	}, d.DeliverSms)
+	return err
+}
+
+func (d *Driver) VerifyPhone(cmd *ports.CommandUserPhoneVerify) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	// The attempt is counted before checking, so concurrent guesses
+	// can't exceed the limit.
+	var v domain.UserPhoneVerification
+	if err := d.Users.ConsumePhoneVerificationAttempt(&v, cmd.UserId); err != nil {
+		return err
+	}
+	if v.Attempts > d.Conf.PhoneCodeMaxAttempts || time.Now().After(v.ExpiresAt) {
+		return domain.ErrExpired
+	}
+
+	digest := phoneCodeDigest(cmd.UserId, cmd.Code)
+	if subtle.ConstantTimeCompare(digest, v.CodeDigest) != 1 {
+		return domain.ErrCredentials
+	}
+
+	return d.Users.VerifyPhone(cmd.UserId)
+}
+
+func (d *Driver) RequestPasswordReset(cmd *ports.CommandUserPasswordResetRequest) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	// Every refusal is silent to not disclose whether the email
+	// is registered.
+	var u domain.User
+	if err := d.Users.FindByEmail(&u, cmd.Email); err != nil {
+		if err == domain.ErrKey {
+			return nil
+		}
+		return err
+	}
+
+	now := time.Now()
+
+	var r domain.UserPasswordReset
+	switch err := d.Users.FindPasswordReset(&r, u.Id); err {
+	case nil:
+		if now.Before(r.SentAt.Add(time.Duration(d.Conf.PasswordResetInterval))) {
+			return nil
+		}
+	case domain.ErrKey:
+	default:
+		return err
+	}
+
+	token, digest, err := newSecretToken()
+	if err != nil {
+		return err
+	}
+
+	r.Reset()
+	r.UserId = u.Id
+	r.TokenDigest = digest
+	r.SentAt = now
+	r.ExpiresAt = now.Add(time.Duration(d.Conf.PasswordResetTtl))
+	if err := d.Users.SavePasswordReset(&r); err != nil {
+		return err
+	}
+
+	_, err = d.Texts.Submit(u.Email, func(dst []byte) ([]byte, error) {
+		dst = append(dst, "Subject: Reset your password\r\n\r\n"...)
+		dst = append(dst, "Follow the link to set a new password:\r\n"...)
+		dst = append(dst, d.Conf.PasswordResetUrl...)
+		dst = append(dst, token...)
+		dst = append(dst, "\r\n\r\nThe link expires at "...)
+		dst = r.ExpiresAt.UTC().AppendFormat(dst, time.RFC1123)
+		dst = append(dst, ". Ignore this email if you didn't ask for a reset.\r\n"...)
+		return dst, nil
+	}, d.DeliverEmail)
+	return err
+}
+
+func (d *Driver) ResetPassword(cmd *ports.CommandUserPasswordReset) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	// Check the token before spending the hashing capacity on it.
+	// ResetPassword checks it again, consuming the reset atomically.
+	tokenDigest := secretTokenDigest(cmd.Token)
+	var r domain.UserPasswordReset
+	if err := d.Users.FindPasswordResetByToken(&r, tokenDigest); err != nil {
+		return err
+	}
+	if r.ExpiresAt.Before(time.Now()) {
+		return domain.ErrExpired
+	}
+
+	passwordDigest, err := d.PasswordHasher.Hash(cmd.Password)
+	if err != nil {
+		return err
+	}
+
+	userId, err := d.Users.ResetPassword(tokenDigest, passwordDigest)
+	if err != nil {
+		return err
+	}
+
+	// Whoever knew the old password must not stay logged in. The
+	// password is replaced already, and the token is used up, so
+	// an error would only make the caller think the reset failed.
+	if _, err := d.Sessions.TerminateAllForUser(userId, 0); err != nil {
+		log.Println("DriverUser: can't terminate sessions after password reset:", err)
+	}
 	return nil
 }
+
+func (d *Driver) ChangePassword(cmd *ports.CommandUserPasswordChange) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	var u domain.User
+	if err := d.Users.FindById(&u, cmd.UserId); err != nil {
+		return err
+	}
+
+	ok, err := d.PasswordHasher.Verify(u.PasswordDigest, cmd.CurrentPassword)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrCredentials
+	}
+
+	passwordDigest, err := d.PasswordHasher.Hash(cmd.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := d.Users.UpdatePasswordDigest(cmd.UserId, passwordDigest); err != nil {
+		return err
+	}
+
+	if !cmd.TerminateOthers {
+		return nil
+	}
+	n, err := d.Sessions.TerminateAllForUser(cmd.UserId, cmd.SessionId)
+	if err != nil {
+		return err
+	}
+	cmd.Result.Terminated = n
+	return nil
+}
+
+func (d *Driver) EnrollTotp(cmd *ports.CommandUserTotpEnroll) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	var u domain.User
+	if err := d.Users.FindById(&u, cmd.UserId); err != nil {
+		return err
+	}
+	if u.TotpEnabled {
+		return domain.ErrExists
+	}
+
+	secret, err := newTotpSecret()
+	if err != nil {
+		return err
+	}
+	sealed, err := sealTotpSecret(d.Conf.TotpSecretKey, u.Id, secret)
+	if err != nil {
+		return err
+	}
+	if err := d.Users.SaveTotpSecret(u.Id, sealed); err != nil {
+		if err == domain.ErrKey {
+			// Enabled concurrently.
+			return domain.ErrExists
+		}
+		return err
+	}
+	codes, err := d.newRecoveryCodes(u.Id, cmd.Result.RecoveryCodes[:0])
+	if err != nil {
+		return err
+	}
+
+	cmd.Result.Secret = totpSecretEncoding.EncodeToString(secret)
+	cmd.Result.Uri = totpUri(d.Conf.TotpIssuer, u.Email, secret)
+	cmd.Result.RecoveryCodes = codes
+	return nil
+}
+
+func (d *Driver) ConfirmTotp(cmd *ports.CommandUserTotpConfirm) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	var u domain.User
+	if err := d.Users.FindById(&u, cmd.UserId); err != nil {
+		return err
+	}
+	if u.TotpEnabled {
+		return domain.ErrExists
+	}
+	if len(u.TotpSecret) == 0 {
+		return domain.ErrKey
+	}
+
+	step, err := d.matchTotp(&u, cmd.Code)
+	if err != nil {
+		return err
+	}
+	return d.Users.EnableTotp(u.Id, step)
+}
+
+func (d *Driver) DisableTotp(cmd *ports.CommandUserTotpDisable) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	var u domain.User
+	if err := d.Users.FindById(&u, cmd.UserId); err != nil {
+		return err
+	}
+	if !u.TotpEnabled {
+		return domain.ErrKey
+	}
+
+	ok, err := d.PasswordHasher.Verify(u.PasswordDigest, cmd.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrCredentials
+	}
+
+	if err := d.useSecondFactor(&u, cmd.Code); err != nil {
+		return err
+	}
+	return d.Users.DisableTotp(u.Id)
+}
+
+func (d *Driver) VerifyLoginTotp(cmd *ports.CommandUserLoginTotp) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	// The attempt is counted before checking, so concurrent guesses
+	// can't exceed the limit.
+	digest := secretTokenDigest(cmd.Challenge)
+	var c domain.UserLoginChallenge
+	if err := d.Users.ConsumeLoginChallengeAttempt(&c, digest); err != nil {
+		return err
+	}
+	if c.Attempts > d.Conf.LoginChallengeMaxAttempts || time.Now().After(c.ExpiresAt) {
+		return domain.ErrExpired
+	}
+
+	u := &cmd.Result
+	if err := d.Users.FindById(u, c.UserId); err != nil {
+		return err
+	}
+	if !u.TotpEnabled {
+		// Disabled since the challenge was issued.
+		return domain.ErrKey
+	}
+
+	now := time.Now()
+	account, addr := loginThrottleKeys(u.Email, cmd.IpAddr)
+	if err := d.checkLoginThrottle(&cmd.RetryAfter, account, addr, now); err != nil {
+		return err
+	}
+
+	if err := d.useSecondFactor(u, cmd.Code); err != nil {
+		if err == domain.ErrCredentials {
+			return d.loginFailed(account, addr, now)
+		}
+		return err
+	}
+	if err := d.Users.DeleteLoginChallenge(digest); err != nil {
+		return err
+	}
+
+	d.resetLoginThrottle(account)
+	return nil
+}
+
+func (d *Driver) RegenerateRecoveryCodes(cmd *ports.CommandUserRecoveryCodesRegenerate) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	var u domain.User
+	if err := d.Users.FindById(&u, cmd.UserId); err != nil {
+		return err
+	}
+	if !u.TotpEnabled {
+		return domain.ErrKey
+	}
+
+	ok, err := d.PasswordHasher.Verify(u.PasswordDigest, cmd.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrCredentials
+	}
+
+	if err := d.useSecondFactor(&u, cmd.Code); err != nil {
+		return err
+	}
+
+	codes, err := d.newRecoveryCodes(u.Id, cmd.Result.Codes[:0])
+	if err != nil {
+		return err
+	}
+	cmd.Result.Codes = codes
+	return nil
+}
+
+func (d *Driver) CountRecoveryCodes(cmd *ports.CommandUserRecoveryCodesCount) error {
+	if !cmd.IsValid() {
+		return domain.ErrValue
+	}
+
+	n, err := d.Users.CountRecoveryCodes(cmd.UserId)
+	if err != nil {
+		return err
+	}
+	cmd.Result.Remaining = n
+	return nil
+}
+
+// newRecoveryCodes replaces the user recovery codes with a new set
+// appended to dst.
+func (d *Driver) newRecoveryCodes(userId int64, dst []string) ([]string, error) {
+	codes := make([]domain.UserRecoveryCode, 0, ports.RecoveryCodeCount)
+	plain := make([]string, 0, ports.RecoveryCodeCount)
+	for len(plain) < ports.RecoveryCodeCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return dst, err
+		}
+		plain = append(plain, code)
+		codes = append(codes, domain.UserRecoveryCode{
+			UserId:     userId,
+			CodeDigest: d.CodeDigester.Digest(code),
+		})
+	}
+
+	if err := d.Users.ReplaceRecoveryCodes(userId, codes); err != nil {
+		return dst, err
+	}
+	return append(dst, plain...), nil
+}
+
+// useSecondFactor checks the TOTP or recovery code and makes sure
+// it is accepted once.
+func (d *Driver) useSecondFactor(u *domain.User, code string) error {
+	if !ports.IsRecoveryCode(code) {
+		return d.useTotp(u, code)
+	}
+
+	var c domain.UserRecoveryCode
+	switch err := d.Users.FindRecoveryCode(&c, u.Id, d.CodeDigester.Digest(code)); err {
+	case nil:
+	case domain.ErrKey:
+		return domain.ErrCredentials
+	default:
+		return err
+	}
+
+	if err := d.Users.UseRecoveryCode(c.Id); err != nil {
+		if err == domain.ErrExpired {
+			return domain.ErrCredentials
+		}
+		return err
+	}
+	return nil
+}
+
+// matchTotp returns the time step of the code for the user secret.
+func (d *Driver) matchTotp(u *domain.User, code string) (int64, error) {
+	secret, err := openTotpSecret(d.Conf.TotpSecretKey, u.Id, u.TotpSecret)
+	if err != nil {
+		return 0, err
+	}
+	step, ok := matchTotp(secret, code, time.Now(), d.Conf.TotpSkew)
+	if !ok || step <= u.TotpLastStep {
+		return 0, domain.ErrCredentials
+	}
+	return step, nil
+}
+
+// useTotp checks the code and marks its step as used, so the code
+// is accepted once.
+func (d *Driver) useTotp(u *domain.User, code string) error {
+	step, err := d.matchTotp(u, code)
+	if err != nil {
+		return err
+	}
+	if err := d.Users.UseTotpStep(u.Id, step); err != nil {
+		if err == domain.ErrExpired {
+			return domain.ErrCredentials
+		}
+		return err
+	}
+	return nil
+}
+
+// sendEmailConfirmation replaces the user pending confirmation with
+// a new one and submits its token for delivery.
+// The confirmation expires along with the confirmation period.
+func (d *Driver) sendEmailConfirmation(u *domain.User) error {
+	token, digest, err := newSecretToken()
+	if err != nil {
+		return err
+	}
+
+	c := domain.UserEmailConfirmation{
+		UserId:      u.Id,
+		TokenDigest: digest,
+		SentAt:      time.Now(),
+		ExpiresAt:   u.CreatedAt.Add(time.Duration(d.Conf.EmailConfirmTtl)),
+	}
+	if err := d.Users.SaveEmailConfirmation(&c); err != nil {
+		return err
+	}
+
+	_, err = d.Texts.Submit(u.Email, func(dst []byte) ([]byte, error) {
+		dst = append(dst, "Subject: Confirm your email\r\n\r\n"...)
+		dst = append(dst, "Follow the link to confirm your email:\r\n"...)
+		dst = append(dst, d.Conf.EmailConfirmUrl...)
+		dst = append(dst, token...)
+		dst = append(dst, "\r\n\r\nThe link expires at "...)
+		dst = c.ExpiresAt.UTC().AppendFormat(dst, time.RFC1123)
+		dst = append(dst, ".\r\n"...)
+		return dst, nil
+	}, d.DeliverEmail)
+	return err
+}
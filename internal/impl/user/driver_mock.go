@@ -34,6 +34,90 @@ func (m *MockDriverUser) EXPECT() *MockDriverUserMockRecorder {
 	return m.recorder
 }
 
+// AttachPhone mocks base method.
+func (m *MockDriverUser) AttachPhone(arg0 *ports.CommandUserPhoneAttach) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachPhone", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AttachPhone indicates an expected call of AttachPhone.
+func (mr *MockDriverUserMockRecorder) AttachPhone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachPhone", reflect.TypeOf((*MockDriverUser)(nil).AttachPhone), arg0)
+}
+
+// Authenticate mocks base method.
+func (m *MockDriverUser) Authenticate(arg0 *ports.CommandUserAuthenticate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockDriverUserMockRecorder) Authenticate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockDriverUser)(nil).Authenticate), arg0)
+}
+
+// ChangePassword mocks base method.
+func (m *MockDriverUser) ChangePassword(arg0 *ports.CommandUserPasswordChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockDriverUserMockRecorder) ChangePassword(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockDriverUser)(nil).ChangePassword), arg0)
+}
+
+// ConfirmEmail mocks base method.
+func (m *MockDriverUser) ConfirmEmail(arg0 *ports.CommandUserEmailConfirm) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmail", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmEmail indicates an expected call of ConfirmEmail.
+func (mr *MockDriverUserMockRecorder) ConfirmEmail(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmail", reflect.TypeOf((*MockDriverUser)(nil).ConfirmEmail), arg0)
+}
+
+// ConfirmTotp mocks base method.
+func (m *MockDriverUser) ConfirmTotp(arg0 *ports.CommandUserTotpConfirm) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTotp", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmTotp indicates an expected call of ConfirmTotp.
+func (mr *MockDriverUserMockRecorder) ConfirmTotp(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTotp", reflect.TypeOf((*MockDriverUser)(nil).ConfirmTotp), arg0)
+}
+
+// CountRecoveryCodes mocks base method.
+func (m *MockDriverUser) CountRecoveryCodes(arg0 *ports.CommandUserRecoveryCodesCount) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRecoveryCodes", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CountRecoveryCodes indicates an expected call of CountRecoveryCodes.
+func (mr *MockDriverUserMockRecorder) CountRecoveryCodes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRecoveryCodes", reflect.TypeOf((*MockDriverUser)(nil).CountRecoveryCodes), arg0)
+}
+
 // Create mocks base method.
 func (m *MockDriverUser) Create(arg0 *ports.CommandUserCreate) error {
 	m.ctrl.T.Helper()
@@ -48,6 +132,148 @@ func (mr *MockDriverUserMockRecorder) Create(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDriverUser)(nil).Create), arg0)
 }
 
+// DisableTotp mocks base method.
+func (m *MockDriverUser) DisableTotp(arg0 *ports.CommandUserTotpDisable) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTotp", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTotp indicates an expected call of DisableTotp.
+func (mr *MockDriverUserMockRecorder) DisableTotp(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTotp", reflect.TypeOf((*MockDriverUser)(nil).DisableTotp), arg0)
+}
+
+// EnrollTotp mocks base method.
+func (m *MockDriverUser) EnrollTotp(arg0 *ports.CommandUserTotpEnroll) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTotp", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnrollTotp indicates an expected call of EnrollTotp.
+func (mr *MockDriverUserMockRecorder) EnrollTotp(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTotp", reflect.TypeOf((*MockDriverUser)(nil).EnrollTotp), arg0)
+}
+
+// PurgeLoginThrottles mocks base method.
+func (m *MockDriverUser) PurgeLoginThrottles() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeLoginThrottles")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeLoginThrottles indicates an expected call of PurgeLoginThrottles.
+func (mr *MockDriverUserMockRecorder) PurgeLoginThrottles() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeLoginThrottles", reflect.TypeOf((*MockDriverUser)(nil).PurgeLoginThrottles))
+}
+
+// PurgeUnconfirmed mocks base method.
+func (m *MockDriverUser) PurgeUnconfirmed() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUnconfirmed")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeUnconfirmed indicates an expected call of PurgeUnconfirmed.
+func (mr *MockDriverUserMockRecorder) PurgeUnconfirmed() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUnconfirmed", reflect.TypeOf((*MockDriverUser)(nil).PurgeUnconfirmed))
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockDriverUser) RegenerateRecoveryCodes(arg0 *ports.CommandUserRecoveryCodesRegenerate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockDriverUserMockRecorder) RegenerateRecoveryCodes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockDriverUser)(nil).RegenerateRecoveryCodes), arg0)
+}
+
+// RequestPasswordReset mocks base method.
+func (m *MockDriverUser) RequestPasswordReset(arg0 *ports.CommandUserPasswordResetRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockDriverUserMockRecorder) RequestPasswordReset(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockDriverUser)(nil).RequestPasswordReset), arg0)
+}
+
+// ResendEmailConfirmation mocks base method.
+func (m *MockDriverUser) ResendEmailConfirmation(arg0 *ports.CommandUserEmailConfirmationResend) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendEmailConfirmation", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResendEmailConfirmation indicates an expected call of ResendEmailConfirmation.
+func (mr *MockDriverUserMockRecorder) ResendEmailConfirmation(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendEmailConfirmation", reflect.TypeOf((*MockDriverUser)(nil).ResendEmailConfirmation), arg0)
+}
+
+// ResetPassword mocks base method.
+func (m *MockDriverUser) ResetPassword(arg0 *ports.CommandUserPasswordReset) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockDriverUserMockRecorder) ResetPassword(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockDriverUser)(nil).ResetPassword), arg0)
+}
+
+// VerifyLoginTotp mocks base method.
+func (m *MockDriverUser) VerifyLoginTotp(arg0 *ports.CommandUserLoginTotp) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyLoginTotp", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyLoginTotp indicates an expected call of VerifyLoginTotp.
+func (mr *MockDriverUserMockRecorder) VerifyLoginTotp(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyLoginTotp", reflect.TypeOf((*MockDriverUser)(nil).VerifyLoginTotp), arg0)
+}
+
+// VerifyPhone mocks base method.
+func (m *MockDriverUser) VerifyPhone(arg0 *ports.CommandUserPhoneVerify) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyPhone", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyPhone indicates an expected call of VerifyPhone.
+func (mr *MockDriverUserMockRecorder) VerifyPhone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyPhone", reflect.TypeOf((*MockDriverUser)(nil).VerifyPhone), arg0)
+}
+
 // MockPasswordHasher is a mock of PasswordHasher interface.
 type MockPasswordHasher struct {
 	ctrl     *gomock.Controller
@@ -85,3 +311,69 @@ func (mr *MockPasswordHasherMockRecorder) Hash(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hash", reflect.TypeOf((*MockPasswordHasher)(nil).Hash), arg0)
 }
+
+// NeedsRehash mocks base method.
+func (m *MockPasswordHasher) NeedsRehash(digest []byte) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NeedsRehash", digest)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// NeedsRehash indicates an expected call of NeedsRehash.
+func (mr *MockPasswordHasherMockRecorder) NeedsRehash(digest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NeedsRehash", reflect.TypeOf((*MockPasswordHasher)(nil).NeedsRehash), digest)
+}
+
+// Verify mocks base method.
+func (m *MockPasswordHasher) Verify(digest []byte, password string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", digest, password)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockPasswordHasherMockRecorder) Verify(digest, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockPasswordHasher)(nil).Verify), digest, password)
+}
+
+// MockSecretDigester is a mock of SecretDigester interface.
+type MockSecretDigester struct {
+	ctrl     *gomock.Controller
+	recorder *MockSecretDigesterMockRecorder
+}
+
+// MockSecretDigesterMockRecorder is the mock recorder for MockSecretDigester.
+type MockSecretDigesterMockRecorder struct {
+	mock *MockSecretDigester
+}
+
+// NewMockSecretDigester creates a new mock instance.
+func NewMockSecretDigester(ctrl *gomock.Controller) *MockSecretDigester {
+	mock := &MockSecretDigester{ctrl: ctrl}
+	mock.recorder = &MockSecretDigesterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSecretDigester) EXPECT() *MockSecretDigesterMockRecorder {
+	return m.recorder
+}
+
+// Digest mocks base method.
+func (m *MockSecretDigester) Digest(secret string) []byte {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Digest", secret)
+	ret0, _ := ret[0].([]byte)
+	return ret0
+}
+
+// Digest indicates an expected call of Digest.
+func (mr *MockSecretDigesterMockRecorder) Digest(secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Digest", reflect.TypeOf((*MockSecretDigester)(nil).Digest), secret)
+}
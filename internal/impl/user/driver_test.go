@@ -1,14 +1,19 @@
 package user
 
 import (
+	"bytes"
+	"net"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/boris-army/server/internal/config"
 	"github.com/boris-army/server/internal/core/domain"
 	"github.com/boris-army/server/internal/core/ports"
+	"github.com/boris-army/server/internal/impl/session"
 )
 
 func TestDriver_Create_Ok(t *testing.T) {
@@ -17,7 +22,8 @@ func TestDriver_Create_Ok(t *testing.T) {
 
 	repoUser := NewMockRepositoryUser(ctrl)
 	passHasher := NewMockPasswordHasher(ctrl)
-	d := Driver{Users: repoUser, PasswordHasher: passHasher}
+	texts := NewMockDriverTextNQ(ctrl)
+	d := Driver{Users: repoUser, PasswordHasher: passHasher, Texts: texts, Conf: testConf()}
 
 	cmd := ports.CommandUserCreate{
 		Email:      "pgarin@old.me",
@@ -34,7 +40,85 @@ func TestDriver_Create_Ok(t *testing.T) {
 		Surname:        "Garin",
 		GivenNames:     "Pyotr",
 		PasswordDigest: []byte("foo"),
-	}).Return(nil)
+	}).DoAndReturn(func(u *domain.User) error {
+		u.Id = 1
+		return nil
+	})
+
+	var digest []byte
+	repoUser.EXPECT().SaveEmailConfirmation(gomock.Any()).
+		DoAndReturn(func(c *domain.UserEmailConfirmation) error {
+			assert.Equal(t, int64(1), c.UserId)
+			digest = c.TokenDigest
+			return nil
+		})
+	expectEmailSubmit(t, texts, "pgarin@old.me", func(token string) {
+		assert.Equal(t, digest, secretTokenDigest(token))
+	})
+
+	assert.Equal(t, nil, d.Create(&cmd))
+}
+
+func testConf() *config.User {
+	return &config.User{
+		EmailConfirmUrl:           "https://boris.army/confirm?token=",
+		EmailConfirmTtl:           config.Duration(time.Hour * 24),
+		EmailResendInterval:       config.Duration(time.Minute),
+		PhoneCodeTtl:              config.Duration(time.Minute * 10),
+		PhoneCodeMaxAttempts:      3,
+		PhoneResendInterval:       config.Duration(time.Minute),
+		PasswordResetUrl:          "https://boris.army/reset-password?token=",
+		PasswordResetTtl:          config.Duration(time.Hour),
+		PasswordResetInterval:     config.Duration(time.Minute),
+		TotpIssuer:                "Boris",
+		TotpSecretKey:             "0123456789abcdef0123456789abcdef",
+		TotpSkew:                  1,
+		LoginChallengeTtl:         config.Duration(time.Minute * 5),
+		LoginChallengeMaxAttempts: 3,
+		LoginFailureWindow:        config.Duration(time.Hour),
+		LoginDelayBase:            config.Duration(time.Second),
+		LoginLockoutTtl:           config.Duration(time.Minute * 15),
+		LoginFreeFailures:         3,
+		LoginLockoutFailures:      10,
+		LoginIpFreeFailures:       20,
+		LoginIpLockoutFailures:    100,
+	}
+}
+
+// expectEmailSubmit expects a message to the recipient and passes
+// the token found in the rendered message to checkFn.
+func expectEmailSubmit(t *testing.T, texts *MockDriverTextNQ, recipient string, checkFn func(token string)) {
+	texts.EXPECT().Submit(recipient, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ string, renderFn ports.DriverTextNSRenderFn, _ ports.DriverTextNSDeliverFn) (bool, error) {
+			data, err := renderFn(nil)
+			assert.Nil(t, err)
+
+			const prefix = "?token="
+			i := bytes.Index(data, []byte(prefix))
+			assert.True(t, i >= 0)
+			checkFn(string(data[i+len(prefix) : i+len(prefix)+ports.SecretTokenLen]))
+			return true, nil
+		})
+}
+
+func TestDriver_Create_ConfirmationFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoUser := NewMockRepositoryUser(ctrl)
+	passHasher := NewMockPasswordHasher(ctrl)
+	d := Driver{Users: repoUser, PasswordHasher: passHasher, Conf: testConf()}
+
+	cmd := ports.CommandUserCreate{
+		Email:      "pgarin@old.me",
+		Surname:    "Garin",
+		GivenNames: "Pyotr",
+		Password:   "qwerty123",
+	}
+
+	passHasher.EXPECT().Hash(cmd.Password).Return([]byte("foo"), nil)
+	repoUser.EXPECT().Create(gomock.Any()).Return(nil)
+	repoUser.EXPECT().SaveEmailConfirmation(gomock.Any()).Return(os.ErrNoDeadline)
 
 	assert.Equal(t, nil, d.Create(&cmd))
 }
@@ -104,3 +188,1025 @@ func TestDriver_Create_RepoUserErrOtherProxy(t *testing.T) {
 
 	assert.Equal(t, os.ErrNoDeadline, d.Create(&cmd))
 }
+
+func TestDriver_Authenticate(t *testing.T) {
+	type tc struct {
+		name        string
+		findErr     error
+		verifyOk    bool
+		verifyErr   error
+		expectsHash bool
+		needsRehash bool
+		updateErr   error
+		expDigest   string
+		expErr      error
+	}
+	tcs := []tc{
+		{"ok", nil, true, nil, true, false, nil, "foo", nil},
+		{"ok rehash", nil, true, nil, true, true, nil, "bar", nil},
+		{"ok rehash failed", nil, true, nil, true, true, os.ErrNoDeadline, "foo", nil},
+		{"no user", domain.ErrKey, false, nil, false, false, nil, "foo", domain.ErrCredentials},
+		{"find internal", os.ErrNoDeadline, false, nil, false, false, nil, "foo", os.ErrNoDeadline},
+		{"mismatch", nil, false, nil, true, false, nil, "foo", domain.ErrCredentials},
+		{"malformed digest", nil, false, os.ErrInvalid, true, false, nil, "foo", os.ErrInvalid},
+		{"overloaded", nil, false, domain.ErrOverloaded, true, false, nil, "foo", domain.ErrOverloaded},
+		{"no user overloaded", domain.ErrKey, false, domain.ErrOverloaded, false, false, nil, "foo", domain.ErrOverloaded},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repoUser := NewMockRepositoryUser(ctrl)
+			passHasher := NewMockPasswordHasher(ctrl)
+			throttles := NewMockRepositoryLoginThrottle(ctrl)
+			d := Driver{Users: repoUser, PasswordHasher: passHasher, Throttles: throttles, Conf: testConf()}
+
+			cmd := ports.CommandUserAuthenticate{
+				Email:    "pgarin@old.me",
+				Password: "qwerty123",
+			}
+			assert.True(t, cmd.IsValid())
+
+			throttles.EXPECT().FindLockedUntil("email:pgarin@old.me").Return(time.Time{}, nil)
+			if tc.expErr == domain.ErrCredentials {
+				throttles.EXPECT().RecordFailure(gomock.Any(), "email:pgarin@old.me", gomock.Any(), gomock.Any()).
+					DoAndReturn(func(dst *domain.LoginThrottle, key string, _, _ time.Time) error {
+						dst.Key = key
+						dst.Failures = 1
+						return nil
+					})
+			}
+
+			repoUser.EXPECT().FindByEmail(&cmd.Result, cmd.Email).
+				DoAndReturn(func(dst *domain.User, _ string) error {
+					dst.Id = 1
+					dst.PasswordDigest = []byte("foo")
+					return tc.findErr
+				})
+			if tc.expectsHash {
+				passHasher.EXPECT().Verify([]byte("foo"), cmd.Password).Return(tc.verifyOk, tc.verifyErr)
+			}
+			if tc.findErr == domain.ErrKey {
+				passHasher.EXPECT().Hash("dummy password").Return([]byte("dummy"), nil)
+				passHasher.EXPECT().Verify([]byte("dummy"), cmd.Password).Return(false, tc.verifyErr)
+			}
+			if tc.verifyOk {
+				throttles.EXPECT().Reset("email:pgarin@old.me").Return(nil)
+				passHasher.EXPECT().NeedsRehash([]byte("foo")).Return(tc.needsRehash)
+			}
+			if tc.needsRehash {
+				passHasher.EXPECT().Hash(cmd.Password).Return([]byte("bar"), nil)
+				repoUser.EXPECT().UpdatePasswordDigest(int64(1), []byte("bar")).Return(tc.updateErr)
+			}
+
+			assert.Equal(t, tc.expErr, d.Authenticate(&cmd))
+			assert.Equal(t, tc.expDigest, string(cmd.Result.PasswordDigest))
+		})
+	}
+}
+
+func TestDriver_Authenticate_InvalidCommand(t *testing.T) {
+	d := Driver{}
+
+	cmd := ports.CommandUserAuthenticate{Email: "pgarin"}
+	assert.False(t, cmd.IsValid())
+	assert.Equal(t, domain.ErrValue, d.Authenticate(&cmd))
+}
+
+func TestDriver_Authenticate_Throttled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	throttles := NewMockRepositoryLoginThrottle(ctrl)
+	d := Driver{Throttles: throttles, Conf: testConf()}
+
+	cmd := ports.CommandUserAuthenticate{
+		Email:    "pgarin@old.me",
+		Password: "qwerty123",
+		IpAddr:   net.ParseIP("2001:db8::1"),
+	}
+
+	throttles.EXPECT().FindLockedUntil("email:pgarin@old.me", "ip:2001:db8::").
+		Return(time.Now().Add(time.Minute), nil)
+
+	assert.Equal(t, domain.ErrThrottled, d.Authenticate(&cmd))
+	assert.True(t, cmd.RetryAfter > time.Second*50 && cmd.RetryAfter <= time.Minute)
+}
+
+func TestDriver_Authenticate_LoginFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoUser := NewMockRepositoryUser(ctrl)
+	passHasher := NewMockPasswordHasher(ctrl)
+	throttles := NewMockRepositoryLoginThrottle(ctrl)
+	d := Driver{Users: repoUser, PasswordHasher: passHasher, Throttles: throttles, Conf: testConf()}
+
+	cmd := ports.CommandUserAuthenticate{
+		Email:    "pgarin@old.me",
+		Password: "qwerty123",
+		IpAddr:   net.ParseIP("127.0.0.1"),
+	}
+
+	throttles.EXPECT().FindLockedUntil("email:pgarin@old.me", "ip:127.0.0.1").Return(time.Time{}, nil)
+	repoUser.EXPECT().FindByEmail(&cmd.Result, cmd.Email).Return(domain.ErrKey)
+	passHasher.EXPECT().Hash(gomock.Any()).Return([]byte("dummy"), nil)
+	passHasher.EXPECT().Verify([]byte("dummy"), cmd.Password).Return(false, nil)
+
+	var failedAt time.Time
+	throttles.EXPECT().RecordFailure(gomock.Any(), "email:pgarin@old.me", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(dst *domain.LoginThrottle, _ string, at, windowStart time.Time) error {
+			assert.Equal(t, time.Hour, at.Sub(windowStart))
+			failedAt = at
+			dst.Failures = 5
+			return nil
+		})
+	throttles.EXPECT().Lock("email:pgarin@old.me", gomock.Any()).
+		DoAndReturn(func(_ string, until time.Time) error {
+			assert.Equal(t, time.Second*2, until.Sub(failedAt))
+			return nil
+		})
+	throttles.EXPECT().RecordFailure(gomock.Any(), "ip:127.0.0.1", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(dst *domain.LoginThrottle, _ string, _, _ time.Time) error {
+			dst.Failures = 5
+			return nil
+		})
+
+	assert.Equal(t, domain.ErrCredentials, d.Authenticate(&cmd))
+}
+
+func TestDriver_Authenticate_DummyHashOverloaded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoUser := NewMockRepositoryUser(ctrl)
+	passHasher := NewMockPasswordHasher(ctrl)
+	throttles := NewMockRepositoryLoginThrottle(ctrl)
+	d := Driver{Users: repoUser, PasswordHasher: passHasher, Throttles: throttles, Conf: testConf()}
+
+	cmd := ports.CommandUserAuthenticate{
+		Email:    "pgarin@old.me",
+		Password: "qwerty123",
+	}
+
+	// As for a registered email, the overload is no failed login.
+	throttles.EXPECT().FindLockedUntil("email:pgarin@old.me").Return(time.Time{}, nil)
+	repoUser.EXPECT().FindByEmail(&cmd.Result, cmd.Email).Return(domain.ErrKey)
+	passHasher.EXPECT().Hash("dummy password").Return(nil, domain.ErrOverloaded)
+	assert.Equal(t, domain.ErrOverloaded, d.Authenticate(&cmd))
+
+	// The dummy digest is made once the hasher is back.
+	throttles.EXPECT().FindLockedUntil("email:pgarin@old.me").Return(time.Time{}, nil)
+	repoUser.EXPECT().FindByEmail(&cmd.Result, cmd.Email).Return(domain.ErrKey)
+	passHasher.EXPECT().Hash("dummy password").Return([]byte("dummy"), nil)
+	passHasher.EXPECT().Verify([]byte("dummy"), cmd.Password).Return(false, nil)
+	throttles.EXPECT().RecordFailure(gomock.Any(), "email:pgarin@old.me", gomock.Any(), gomock.Any()).Return(nil)
+	assert.Equal(t, domain.ErrCredentials, d.Authenticate(&cmd))
+}
+
+func TestDriver_PurgeLoginThrottles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	throttles := NewMockRepositoryLoginThrottle(ctrl)
+	d := Driver{Throttles: throttles, Conf: testConf()}
+
+	throttles.EXPECT().Purge(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(failedBefore, now time.Time) (int, error) {
+			assert.Equal(t, time.Hour, now.Sub(failedBefore))
+			return 3, nil
+		})
+
+	n, err := d.PurgeLoginThrottles()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func TestDriver_loginDelay(t *testing.T) {
+	d := Driver{Conf: testConf()}
+
+	type tc struct {
+		failures int
+		expDelay time.Duration
+	}
+	tcs := []tc{
+		{1, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, time.Second * 2},
+		{9, time.Second * 32},
+		{10, time.Minute * 15},
+		{42, time.Minute * 15},
+	}
+	for _, tc := range tcs {
+		assert.Equal(t, tc.expDelay, d.loginDelay(tc.failures, 3, 10), tc.failures)
+	}
+	assert.Equal(t, time.Minute*15, d.loginDelay(30, 3, 100))
+}
+
+func TestDriver_ResendEmailConfirmation(t *testing.T) {
+	type tc struct {
+		name        string
+		findErr     error
+		hasProof    domain.UserProof
+		createdAgo  time.Duration
+		sentAgo     time.Duration
+		findConfErr error
+		expSend     bool
+		expErr      error
+	}
+	tcs := []tc{
+		{name: "unknown email", findErr: domain.ErrKey},
+		{name: "find internal", findErr: os.ErrNoDeadline, expErr: os.ErrNoDeadline},
+		{name: "confirmed", hasProof: domain.UserProofEmail},
+		{name: "expired", createdAgo: time.Hour * 25},
+		{name: "throttled", createdAgo: time.Hour, sentAgo: time.Second},
+		{name: "ok", createdAgo: time.Hour, sentAgo: time.Minute * 2, expSend: true},
+		{name: "ok no confirmation", createdAgo: time.Hour, findConfErr: domain.ErrKey, expSend: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repoUser := NewMockRepositoryUser(ctrl)
+			texts := NewMockDriverTextNQ(ctrl)
+			d := Driver{Users: repoUser, Texts: texts, Conf: testConf()}
+
+			now := time.Now()
+			repoUser.EXPECT().FindByEmail(gomock.Any(), "pgarin@old.me").
+				DoAndReturn(func(dst *domain.User, email string) error {
+					dst.Id = 1
+					dst.Email = email
+					dst.HasProof = tc.hasProof
+					dst.CreatedAt = now.Add(-tc.createdAgo)
+					return tc.findErr
+				})
+			if tc.findErr == nil && tc.hasProof == 0 && tc.createdAgo < time.Hour*24 {
+				repoUser.EXPECT().FindEmailConfirmation(gomock.Any(), int64(1)).
+					DoAndReturn(func(dst *domain.UserEmailConfirmation, _ int64) error {
+						dst.SentAt = now.Add(-tc.sentAgo)
+						return tc.findConfErr
+					})
+			}
+			if tc.expSend {
+				repoUser.EXPECT().SaveEmailConfirmation(gomock.Any()).Return(nil)
+				expectEmailSubmit(t, texts, "pgarin@old.me", func(string) {})
+			}
+
+			cmd := ports.CommandUserEmailConfirmationResend{Email: "pgarin@old.me"}
+			assert.Equal(t, tc.expErr, d.ResendEmailConfirmation(&cmd))
+		})
+	}
+}
+
+func TestDriver_ConfirmEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoUser := NewMockRepositoryUser(ctrl)
+	d := Driver{Users: repoUser}
+
+	token, digest, err := newSecretToken()
+	assert.Nil(t, err)
+
+	repoUser.EXPECT().ConfirmEmail(digest).Return(domain.ErrExpired)
+	assert.Equal(t, domain.ErrExpired, d.ConfirmEmail(&ports.CommandUserEmailConfirm{Token: token}))
+
+	assert.Equal(t, domain.ErrValue, d.ConfirmEmail(&ports.CommandUserEmailConfirm{Token: "foo"}))
+}
+
+func TestDriver_AttachPhone(t *testing.T) {
+	type tc struct {
+		name    string
+		findErr error
+		sentAgo time.Duration
+		expSend bool
+		expErr  error
+	}
+	tcs := []tc{
+		{name: "first code", findErr: domain.ErrKey, expSend: true},
+		{name: "resend", sentAgo: time.Minute * 2, expSend: true},
+		{name: "throttled", sentAgo: time.Second, expErr: domain.ErrThrottled},
+		{name: "find internal", findErr: os.ErrNoDeadline, expErr: os.ErrNoDeadline},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repoUser := NewMockRepositoryUser(ctrl)
+			texts := NewMockDriverTextNQ(ctrl)
+			d := Driver{Users: repoUser, Texts: texts, Conf: testConf()}
+
+			repoUser.EXPECT().FindPhoneVerification(gomock.Any(), int64(1)).
+				DoAndReturn(func(dst *domain.UserPhoneVerification, _ int64) error {
+					dst.SentAt = time.Now().Add(-tc.sentAgo)
+					return tc.findErr
+				})
+			if tc.expSend {
+				var digest []byte
+				repoUser.EXPECT().SavePhoneVerification(gomock.Any()).
+					DoAndReturn(func(v *domain.UserPhoneVerification) error {
+						assert.Equal(t, uint64(79991234567), v.Phone164)
+						assert.Equal(t, 0, v.Attempts)
+						digest = v.CodeDigest
+						return nil
+					})
+				texts.EXPECT().Submit("+79991234567", gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ string, renderFn ports.DriverTextNSRenderFn, _ ports.DriverTextNSDeliverFn) (bool, error) {
+						data, err := renderFn(nil)
+						assert.Nil(t, err)
+						code := string(data[len(data)-ports.PhoneCodeLen:])
+						assert.Equal(t, digest, phoneCodeDigest(1, code))
+						return true, nil
+					})
+			}
+
+			cmd := ports.CommandUserPhoneAttach{UserId: 1, Phone: "+79991234567"}
+			assert.Equal(t, tc.expErr, d.AttachPhone(&cmd))
+		})
+	}
+}
+
+func TestDriver_AttachPhone_InvalidCommand(t *testing.T) {
+	d := Driver{}
+
+	cmd := ports.CommandUserPhoneAttach{UserId: 1, Phone: "89991234567"}
+	assert.False(t, cmd.IsValid())
+	assert.Equal(t, domain.ErrValue, d.AttachPhone(&cmd))
+}
+
+func TestDriver_VerifyPhone(t *testing.T) {
+	type tc struct {
+		name       string
+		code       string
+		attempts   int
+		expiresIn  time.Duration
+		consumeErr error
+		expVerify  bool
+		expErr     error
+	}
+	tcs := []tc{
+		{name: "ok", code: "123456", attempts: 1, expiresIn: time.Minute, expVerify: true},
+		{name: "wrong code", code: "654321", attempts: 1, expiresIn: time.Minute, expErr: domain.ErrCredentials},
+		{name: "out of attempts", code: "123456", attempts: 4, expiresIn: time.Minute, expErr: domain.ErrExpired},
+		{name: "expired", code: "123456", attempts: 1, expiresIn: -time.Minute, expErr: domain.ErrExpired},
+		{name: "no code", code: "123456", consumeErr: domain.ErrKey, expErr: domain.ErrKey},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repoUser := NewMockRepositoryUser(ctrl)
+			d := Driver{Users: repoUser, Conf: testConf()}
+
+			repoUser.EXPECT().ConsumePhoneVerificationAttempt(gomock.Any(), int64(1)).
+				DoAndReturn(func(dst *domain.UserPhoneVerification, _ int64) error {
+					dst.CodeDigest = phoneCodeDigest(1, "123456")
+					dst.Attempts = tc.attempts
+					dst.ExpiresAt = time.Now().Add(tc.expiresIn)
+					return tc.consumeErr
+				})
+			if tc.expVerify {
+				repoUser.EXPECT().VerifyPhone(int64(1)).Return(nil)
+			}
+
+			cmd := ports.CommandUserPhoneVerify{UserId: 1, Code: tc.code}
+			assert.Equal(t, tc.expErr, d.VerifyPhone(&cmd))
+		})
+	}
+}
+
+func TestDriver_RequestPasswordReset(t *testing.T) {
+	type tc struct {
+		name         string
+		findErr      error
+		sentAgo      time.Duration
+		findResetErr error
+		expSend      bool
+		expErr       error
+	}
+	tcs := []tc{
+		{name: "unknown email", findErr: domain.ErrKey},
+		{name: "find internal", findErr: os.ErrNoDeadline, expErr: os.ErrNoDeadline},
+		{name: "throttled", sentAgo: time.Second},
+		{name: "ok", sentAgo: time.Minute * 2, expSend: true},
+		{name: "ok no reset", findResetErr: domain.ErrKey, expSend: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repoUser := NewMockRepositoryUser(ctrl)
+			texts := NewMockDriverTextNQ(ctrl)
+			d := Driver{Users: repoUser, Texts: texts, Conf: testConf()}
+
+			now := time.Now()
+			repoUser.EXPECT().FindByEmail(gomock.Any(), "pgarin@old.me").
+				DoAndReturn(func(dst *domain.User, email string) error {
+					dst.Id = 1
+					dst.Email = email
+					return tc.findErr
+				})
+			if tc.findErr == nil {
+				repoUser.EXPECT().FindPasswordReset(gomock.Any(), int64(1)).
+					DoAndReturn(func(dst *domain.UserPasswordReset, _ int64) error {
+						dst.SentAt = now.Add(-tc.sentAgo)
+						return tc.findResetErr
+					})
+			}
+			if tc.expSend {
+				var digest []byte
+				repoUser.EXPECT().SavePasswordReset(gomock.Any()).
+					DoAndReturn(func(r *domain.UserPasswordReset) error {
+						assert.Equal(t, int64(1), r.UserId)
+						assert.True(t, r.ExpiresAt.After(now.Add(time.Minute*59)))
+						digest = r.TokenDigest
+						return nil
+					})
+				expectEmailSubmit(t, texts, "pgarin@old.me", func(token string) {
+					assert.Equal(t, digest, secretTokenDigest(token))
+				})
+			}
+
+			cmd := ports.CommandUserPasswordResetRequest{Email: "pgarin@old.me"}
+			assert.Equal(t, tc.expErr, d.RequestPasswordReset(&cmd))
+		})
+	}
+}
+
+func TestDriver_ResetPassword(t *testing.T) {
+	token, digest, err := newSecretToken()
+	assert.Nil(t, err)
+
+	type tc struct {
+		name         string
+		cmd          ports.CommandUserPasswordReset
+		findErr      error
+		expiresIn    time.Duration
+		resetErr     error
+		terminateErr error
+		expErr       error
+	}
+	valid := ports.CommandUserPasswordReset{Token: token, Password: "qwerty123"}
+	tcs := []tc{
+		{name: "malformed token", cmd: ports.CommandUserPasswordReset{Token: "foo", Password: "qwerty123"}, expErr: domain.ErrValue},
+		{name: "short password", cmd: ports.CommandUserPasswordReset{Token: token, Password: "qwe"}, expErr: domain.ErrValue},
+		{name: "unknown token", cmd: valid, findErr: domain.ErrKey, expErr: domain.ErrKey},
+		{name: "expired", cmd: valid, expiresIn: -time.Minute, expErr: domain.ErrExpired},
+		{name: "used meanwhile", cmd: valid, expiresIn: time.Hour, resetErr: domain.ErrKey, expErr: domain.ErrKey},
+		{name: "terminate internal", cmd: valid, expiresIn: time.Hour, terminateErr: os.ErrNoDeadline},
+		{name: "ok", cmd: valid, expiresIn: time.Hour},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repoUser := NewMockRepositoryUser(ctrl)
+			passHasher := NewMockPasswordHasher(ctrl)
+			sessions := session.NewMockDriverSession(ctrl)
+			d := Driver{Users: repoUser, PasswordHasher: passHasher, Sessions: sessions}
+
+			if tc.expErr != domain.ErrValue {
+				repoUser.EXPECT().FindPasswordResetByToken(gomock.Any(), digest).
+					DoAndReturn(func(dst *domain.UserPasswordReset, _ []byte) error {
+						dst.ExpiresAt = time.Now().Add(tc.expiresIn)
+						return tc.findErr
+					})
+			}
+			// No hashing capacity is spent on bad tokens.
+			if tc.expiresIn > 0 {
+				passHasher.EXPECT().Hash("qwerty123").Return([]byte("foo"), nil)
+				repoUser.EXPECT().ResetPassword(digest, []byte("foo")).Return(int64(1), tc.resetErr)
+			}
+			if tc.expiresIn > 0 && tc.resetErr == nil {
+				sessions.EXPECT().TerminateAllForUser(int64(1), int64(0)).Return(2, tc.terminateErr)
+			}
+
+			cmd := tc.cmd
+			assert.Equal(t, tc.expErr, d.ResetPassword(&cmd))
+		})
+	}
+}
+
+func TestDriver_ChangePassword(t *testing.T) {
+	type tc struct {
+		name          string
+		cmd           ports.CommandUserPasswordChange
+		findErr       error
+		verifyOk      bool
+		updateErr     error
+		expTerminate  bool
+		expErr        error
+		expTerminated int
+	}
+	valid := ports.CommandUserPasswordChange{UserId: 1, SessionId: 7, CurrentPassword: "qwerty123", NewPassword: "123qwerty"}
+	withTerminate := valid
+	withTerminate.TerminateOthers = true
+	tcs := []tc{
+		{name: "short password", cmd: ports.CommandUserPasswordChange{UserId: 1, SessionId: 7, CurrentPassword: "qwerty123", NewPassword: "qwe"}, expErr: domain.ErrValue},
+		{name: "no user", cmd: valid, findErr: domain.ErrKey, expErr: domain.ErrKey},
+		{name: "wrong password", cmd: valid, expErr: domain.ErrCredentials},
+		{name: "update internal", cmd: valid, verifyOk: true, updateErr: os.ErrNoDeadline, expErr: os.ErrNoDeadline},
+		{name: "ok", cmd: valid, verifyOk: true},
+		{name: "ok terminate others", cmd: withTerminate, verifyOk: true, expTerminate: true, expTerminated: 2},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repoUser := NewMockRepositoryUser(ctrl)
+			passHasher := NewMockPasswordHasher(ctrl)
+			sessions := session.NewMockDriverSession(ctrl)
+			d := Driver{Users: repoUser, PasswordHasher: passHasher, Sessions: sessions}
+
+			if tc.expErr != domain.ErrValue {
+				repoUser.EXPECT().FindById(gomock.Any(), int64(1)).
+					DoAndReturn(func(dst *domain.User, _ int64) error {
+						dst.PasswordDigest = []byte("old")
+						return tc.findErr
+					})
+			}
+			if tc.expErr != domain.ErrValue && tc.findErr == nil {
+				passHasher.EXPECT().Verify([]byte("old"), "qwerty123").Return(tc.verifyOk, nil)
+			}
+			if tc.verifyOk {
+				passHasher.EXPECT().Hash("123qwerty").Return([]byte("new"), nil)
+				repoUser.EXPECT().UpdatePasswordDigest(int64(1), []byte("new")).Return(tc.updateErr)
+			}
+			if tc.expTerminate {
+				sessions.EXPECT().TerminateAllForUser(int64(1), int64(7)).Return(2, nil)
+			}
+
+			cmd := tc.cmd
+			assert.Equal(t, tc.expErr, d.ChangePassword(&cmd))
+			assert.Equal(t, tc.expTerminated, cmd.Result.Terminated)
+		})
+	}
+}
+
+func TestDriver_Authenticate_SecondFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoUser := NewMockRepositoryUser(ctrl)
+	passHasher := NewMockPasswordHasher(ctrl)
+	throttles := NewMockRepositoryLoginThrottle(ctrl)
+	d := Driver{Users: repoUser, PasswordHasher: passHasher, Throttles: throttles, Conf: testConf()}
+
+	cmd := ports.CommandUserAuthenticate{Email: "pgarin@old.me", Password: "qwerty123"}
+
+	// The failures are kept until the second factor passes, no Reset.
+	throttles.EXPECT().FindLockedUntil("email:pgarin@old.me").Return(time.Time{}, nil)
+
+	repoUser.EXPECT().FindByEmail(&cmd.Result, cmd.Email).
+		DoAndReturn(func(dst *domain.User, _ string) error {
+			dst.Id = 1
+			dst.PasswordDigest = []byte("foo")
+			dst.TotpEnabled = true
+			return nil
+		})
+	passHasher.EXPECT().Verify([]byte("foo"), cmd.Password).Return(true, nil)
+	passHasher.EXPECT().NeedsRehash([]byte("foo")).Return(false)
+
+	var digest []byte
+	repoUser.EXPECT().SaveLoginChallenge(gomock.Any()).
+		DoAndReturn(func(c *domain.UserLoginChallenge) error {
+			assert.Equal(t, int64(1), c.UserId)
+			assert.True(t, c.ExpiresAt.After(time.Now()))
+			digest = c.TokenDigest
+			return nil
+		})
+
+	assert.Equal(t, domain.ErrSecondFactor, d.Authenticate(&cmd))
+	assert.Len(t, cmd.Challenge.Token, ports.SecretTokenLen)
+	assert.Equal(t, digest, secretTokenDigest(cmd.Challenge.Token))
+}
+
+// testTotpUser returns a user with 2FA enabled and its secret.
+func testTotpUser(t *testing.T) (domain.User, []byte) {
+	secret, err := newTotpSecret()
+	assert.Nil(t, err)
+	sealed, err := sealTotpSecret(testConf().TotpSecretKey, 1, secret)
+	assert.Nil(t, err)
+
+	return domain.User{
+		Id:             1,
+		Email:          "pgarin@old.me",
+		PasswordDigest: []byte("foo"),
+		TotpSecret:     sealed,
+		TotpEnabled:    true,
+	}, secret
+}
+
+func TestDriver_EnrollTotp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoUser := NewMockRepositoryUser(ctrl)
+	d := Driver{Users: repoUser, CodeDigester: ports.Sha256SecretDigester{}, Conf: testConf()}
+
+	repoUser.EXPECT().FindById(gomock.Any(), int64(1)).
+		DoAndReturn(func(dst *domain.User, _ int64) error {
+			dst.Id = 1
+			dst.Email = "pgarin@old.me"
+			return nil
+		})
+	var stored []domain.UserRecoveryCode
+	repoUser.EXPECT().ReplaceRecoveryCodes(int64(1), gomock.Any()).
+		DoAndReturn(func(_ int64, codes []domain.UserRecoveryCode) error {
+			stored = codes
+			return nil
+		})
+
+	var sealed []byte
+	repoUser.EXPECT().SaveTotpSecret(int64(1), gomock.Any()).
+		DoAndReturn(func(_ int64, secret []byte) error {
+			sealed = secret
+			return nil
+		})
+
+	cmd := ports.CommandUserTotpEnroll{UserId: 1}
+	assert.Nil(t, d.EnrollTotp(&cmd))
+
+	secret, err := openTotpSecret(testConf().TotpSecretKey, 1, sealed)
+	assert.Nil(t, err)
+	assert.Equal(t, totpSecretEncoding.EncodeToString(secret), cmd.Result.Secret)
+	assert.Contains(t, cmd.Result.Uri, "otpauth://totp/Boris:pgarin@old.me?")
+	assert.Contains(t, cmd.Result.Uri, "secret="+cmd.Result.Secret)
+
+	assert.Len(t, cmd.Result.RecoveryCodes, ports.RecoveryCodeCount)
+	assert.Len(t, stored, ports.RecoveryCodeCount)
+	for i, code := range cmd.Result.RecoveryCodes {
+		assert.True(t, ports.IsRecoveryCode(code), code)
+		assert.Equal(t, ports.Sha256SecretDigester{}.Digest(code), stored[i].CodeDigest)
+	}
+}
+
+func TestDriver_EnrollTotp_Enabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoUser := NewMockRepositoryUser(ctrl)
+	d := Driver{Users: repoUser, Conf: testConf()}
+
+	u, _ := testTotpUser(t)
+	repoUser.EXPECT().FindById(gomock.Any(), int64(1)).SetArg(0, u).Return(nil)
+
+	assert.Equal(t, domain.ErrExists, d.EnrollTotp(&ports.CommandUserTotpEnroll{UserId: 1}))
+}
+
+func TestDriver_ConfirmTotp(t *testing.T) {
+	u, secret := testTotpUser(t)
+	u.TotpEnabled = false
+	now := time.Now()
+	code := totpCode(secret, totpStep(now))
+	wrongCode := totpCode(secret, totpStep(now)+5)
+
+	type tc struct {
+		name      string
+		user      domain.User
+		code      string
+		expEnable bool
+		expErr    error
+	}
+	enabled := u
+	enabled.TotpEnabled = true
+	notEnrolled := u
+	notEnrolled.TotpSecret = nil
+	tcs := []tc{
+		{"ok", u, code, true, nil},
+		{"wrong code", u, wrongCode, false, domain.ErrCredentials},
+		{"enabled", enabled, code, false, domain.ErrExists},
+		{"not enrolled", notEnrolled, code, false, domain.ErrKey},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repoUser := NewMockRepositoryUser(ctrl)
+			d := Driver{Users: repoUser, Conf: testConf()}
+
+			repoUser.EXPECT().FindById(gomock.Any(), int64(1)).SetArg(0, tc.user).Return(nil)
+			if tc.expEnable {
+				repoUser.EXPECT().EnableTotp(int64(1), gomock.Any()).Return(nil)
+			}
+
+			cmd := ports.CommandUserTotpConfirm{UserId: 1, Code: tc.code}
+			assert.Equal(t, tc.expErr, d.ConfirmTotp(&cmd))
+		})
+	}
+}
+
+func TestDriver_DisableTotp(t *testing.T) {
+	u, secret := testTotpUser(t)
+	code := totpCode(secret, totpStep(time.Now()))
+
+	type tc struct {
+		name       string
+		passwordOk bool
+		useErr     error
+		expDisable bool
+		expErr     error
+	}
+	tcs := []tc{
+		{"ok", true, nil, true, nil},
+		{"wrong password", false, nil, false, domain.ErrCredentials},
+		{"used code", true, domain.ErrExpired, false, domain.ErrCredentials},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repoUser := NewMockRepositoryUser(ctrl)
+			passHasher := NewMockPasswordHasher(ctrl)
+			d := Driver{Users: repoUser, PasswordHasher: passHasher, Conf: testConf()}
+
+			repoUser.EXPECT().FindById(gomock.Any(), int64(1)).SetArg(0, u).Return(nil)
+			passHasher.EXPECT().Verify([]byte("foo"), "qwerty123").Return(tc.passwordOk, nil)
+			if tc.passwordOk {
+				repoUser.EXPECT().UseTotpStep(int64(1), gomock.Any()).Return(tc.useErr)
+			}
+			if tc.expDisable {
+				repoUser.EXPECT().DisableTotp(int64(1)).Return(nil)
+			}
+
+			cmd := ports.CommandUserTotpDisable{UserId: 1, Password: "qwerty123", Code: code}
+			assert.Equal(t, tc.expErr, d.DisableTotp(&cmd))
+		})
+	}
+}
+
+func TestDriver_VerifyLoginTotp(t *testing.T) {
+	token, digest, err := newSecretToken()
+	assert.Nil(t, err)
+
+	u, secret := testTotpUser(t)
+	now := time.Now()
+	code := totpCode(secret, totpStep(now))
+
+	type tc struct {
+		name       string
+		consumeErr error
+		attempts   int
+		expiresIn  time.Duration
+		lastStep   int64
+		useErr     error
+		expFind    bool
+		expUse     bool
+		expDelete  bool
+		expErr     error
+	}
+	tcs := []tc{
+		{name: "ok", attempts: 1, expiresIn: time.Minute, expFind: true, expUse: true, expDelete: true},
+		{name: "unknown challenge", consumeErr: domain.ErrKey, expErr: domain.ErrKey},
+		{name: "no attempts left", attempts: 4, expiresIn: time.Minute, expErr: domain.ErrExpired},
+		{name: "expired", attempts: 1, expiresIn: -time.Second, expErr: domain.ErrExpired},
+		{name: "step used", attempts: 1, expiresIn: time.Minute, lastStep: totpStep(now) + 1, expFind: true, expErr: domain.ErrCredentials},
+		{name: "replayed", attempts: 1, expiresIn: time.Minute, expFind: true, expUse: true, useErr: domain.ErrExpired, expErr: domain.ErrCredentials},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repoUser := NewMockRepositoryUser(ctrl)
+			throttles := NewMockRepositoryLoginThrottle(ctrl)
+			d := Driver{Users: repoUser, Throttles: throttles, Conf: testConf()}
+
+			repoUser.EXPECT().ConsumeLoginChallengeAttempt(gomock.Any(), digest).
+				DoAndReturn(func(dst *domain.UserLoginChallenge, _ []byte) error {
+					dst.UserId = 1
+					dst.Attempts = tc.attempts
+					dst.ExpiresAt = now.Add(tc.expiresIn)
+					return tc.consumeErr
+				})
+			if tc.expFind {
+				found := u
+				found.TotpLastStep = tc.lastStep
+				repoUser.EXPECT().FindById(gomock.Any(), int64(1)).SetArg(0, found).Return(nil)
+				throttles.EXPECT().FindLockedUntil("email:pgarin@old.me").Return(time.Time{}, nil)
+			}
+			if tc.expUse {
+				repoUser.EXPECT().UseTotpStep(int64(1), totpStep(now)).Return(tc.useErr)
+			}
+			if tc.expErr == domain.ErrCredentials {
+				throttles.EXPECT().RecordFailure(gomock.Any(), "email:pgarin@old.me", gomock.Any(), gomock.Any()).Return(nil)
+			}
+			if tc.expDelete {
+				repoUser.EXPECT().DeleteLoginChallenge(digest).Return(nil)
+				throttles.EXPECT().Reset("email:pgarin@old.me").Return(nil)
+			}
+
+			cmd := ports.CommandUserLoginTotp{Challenge: token, Code: code}
+			assert.Equal(t, tc.expErr, d.VerifyLoginTotp(&cmd))
+		})
+	}
+}
+
+func TestDriver_VerifyLoginTotp_RecoveryCode(t *testing.T) {
+	token, digest, err := newSecretToken()
+	assert.Nil(t, err)
+
+	u, _ := testTotpUser(t)
+	const code = "abcde-fghij-klmn2"
+
+	type tc struct {
+		name      string
+		findErr   error
+		useErr    error
+		expDelete bool
+		expErr    error
+	}
+	tcs := []tc{
+		{name: "ok", expDelete: true},
+		{name: "unknown code", findErr: domain.ErrKey, expErr: domain.ErrCredentials},
+		{name: "used concurrently", useErr: domain.ErrExpired, expErr: domain.ErrCredentials},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repoUser := NewMockRepositoryUser(ctrl)
+			throttles := NewMockRepositoryLoginThrottle(ctrl)
+			digester := NewMockSecretDigester(ctrl)
+			d := Driver{Users: repoUser, CodeDigester: digester, Throttles: throttles, Conf: testConf()}
+
+			repoUser.EXPECT().ConsumeLoginChallengeAttempt(gomock.Any(), digest).
+				DoAndReturn(func(dst *domain.UserLoginChallenge, _ []byte) error {
+					dst.UserId = 1
+					dst.Attempts = 1
+					dst.ExpiresAt = time.Now().Add(time.Minute)
+					return nil
+				})
+			repoUser.EXPECT().FindById(gomock.Any(), int64(1)).SetArg(0, u).Return(nil)
+			throttles.EXPECT().FindLockedUntil("email:pgarin@old.me").Return(time.Time{}, nil)
+			digester.EXPECT().Digest(code).Return([]byte("bar"))
+			repoUser.EXPECT().FindRecoveryCode(gomock.Any(), int64(1), []byte("bar")).
+				DoAndReturn(func(dst *domain.UserRecoveryCode, _ int64, _ []byte) error {
+					dst.Id = 7
+					return tc.findErr
+				})
+			if tc.findErr == nil {
+				repoUser.EXPECT().UseRecoveryCode(int64(7)).Return(tc.useErr)
+			}
+			if tc.expErr == domain.ErrCredentials {
+				throttles.EXPECT().RecordFailure(gomock.Any(), "email:pgarin@old.me", gomock.Any(), gomock.Any()).Return(nil)
+			}
+			if tc.expDelete {
+				repoUser.EXPECT().DeleteLoginChallenge(digest).Return(nil)
+				throttles.EXPECT().Reset("email:pgarin@old.me").Return(nil)
+			}
+
+			cmd := ports.CommandUserLoginTotp{Challenge: token, Code: code}
+			assert.Equal(t, tc.expErr, d.VerifyLoginTotp(&cmd))
+		})
+	}
+}
+
+func TestDriver_VerifyLoginTotp_Lockout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoUser := NewMockRepositoryUser(ctrl)
+	throttles := NewMockRepositoryLoginThrottle(ctrl)
+	d := Driver{Users: repoUser, Throttles: throttles, Conf: testConf()}
+
+	token, digest, err := newSecretToken()
+	assert.Nil(t, err)
+	u, secret := testTotpUser(t)
+	// Every code of the current steps is used up.
+	u.TotpLastStep = totpStep(time.Now()) + 2
+
+	consumeAttempt := func(dst *domain.UserLoginChallenge, _ []byte) error {
+		dst.UserId = 1
+		dst.Attempts = 1
+		dst.ExpiresAt = time.Now().Add(time.Minute)
+		return nil
+	}
+	cmd := ports.CommandUserLoginTotp{
+		Challenge: token,
+		Code:      totpCode(secret, totpStep(time.Now())),
+		IpAddr:    net.ParseIP("127.0.0.1"),
+	}
+
+	// The last free code guess locks the account out.
+	repoUser.EXPECT().ConsumeLoginChallengeAttempt(gomock.Any(), digest).DoAndReturn(consumeAttempt)
+	repoUser.EXPECT().FindById(gomock.Any(), int64(1)).SetArg(0, u).Return(nil)
+	throttles.EXPECT().FindLockedUntil("email:pgarin@old.me", "ip:127.0.0.1").Return(time.Time{}, nil)
+
+	var failedAt time.Time
+	throttles.EXPECT().RecordFailure(gomock.Any(), "email:pgarin@old.me", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(dst *domain.LoginThrottle, _ string, at, _ time.Time) error {
+			failedAt = at
+			dst.Failures = 10
+			return nil
+		})
+	throttles.EXPECT().Lock("email:pgarin@old.me", gomock.Any()).
+		DoAndReturn(func(_ string, until time.Time) error {
+			assert.Equal(t, time.Minute*15, until.Sub(failedAt))
+			return nil
+		})
+	throttles.EXPECT().RecordFailure(gomock.Any(), "ip:127.0.0.1", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(dst *domain.LoginThrottle, _ string, _, _ time.Time) error {
+			dst.Failures = 10
+			return nil
+		})
+
+	assert.Equal(t, domain.ErrCredentials, d.VerifyLoginTotp(&cmd))
+
+	// No code is checked while locked.
+	repoUser.EXPECT().ConsumeLoginChallengeAttempt(gomock.Any(), digest).DoAndReturn(consumeAttempt)
+	repoUser.EXPECT().FindById(gomock.Any(), int64(1)).SetArg(0, u).Return(nil)
+	throttles.EXPECT().FindLockedUntil("email:pgarin@old.me", "ip:127.0.0.1").
+		Return(time.Now().Add(time.Minute*15), nil)
+
+	assert.Equal(t, domain.ErrThrottled, d.VerifyLoginTotp(&cmd))
+	assert.True(t, cmd.RetryAfter > time.Minute*14 && cmd.RetryAfter <= time.Minute*15)
+}
+
+func TestDriver_RegenerateRecoveryCodes(t *testing.T) {
+	u, secret := testTotpUser(t)
+	code := totpCode(secret, totpStep(time.Now()))
+
+	type tc struct {
+		name       string
+		passwordOk bool
+		useErr     error
+		expReplace bool
+		expErr     error
+	}
+	tcs := []tc{
+		{"ok", true, nil, true, nil},
+		{"wrong password", false, nil, false, domain.ErrCredentials},
+		{"used code", true, domain.ErrExpired, false, domain.ErrCredentials},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repoUser := NewMockRepositoryUser(ctrl)
+			passHasher := NewMockPasswordHasher(ctrl)
+			d := Driver{Users: repoUser, PasswordHasher: passHasher, CodeDigester: ports.Sha256SecretDigester{}, Conf: testConf()}
+
+			repoUser.EXPECT().FindById(gomock.Any(), int64(1)).SetArg(0, u).Return(nil)
+			passHasher.EXPECT().Verify([]byte("foo"), "qwerty123").Return(tc.passwordOk, nil)
+			if tc.passwordOk {
+				repoUser.EXPECT().UseTotpStep(int64(1), gomock.Any()).Return(tc.useErr)
+			}
+			if tc.expReplace {
+				repoUser.EXPECT().ReplaceRecoveryCodes(int64(1), gomock.Len(ports.RecoveryCodeCount)).Return(nil)
+			}
+
+			cmd := ports.CommandUserRecoveryCodesRegenerate{UserId: 1, Password: "qwerty123", Code: code}
+			assert.Equal(t, tc.expErr, d.RegenerateRecoveryCodes(&cmd))
+			if tc.expReplace {
+				assert.Len(t, cmd.Result.Codes, ports.RecoveryCodeCount)
+			} else {
+				assert.Empty(t, cmd.Result.Codes)
+			}
+		})
+	}
+
+	t.Run("2fa disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repoUser := NewMockRepositoryUser(ctrl)
+		d := Driver{Users: repoUser, Conf: testConf()}
+
+		u := u
+		u.TotpEnabled = false
+		repoUser.EXPECT().FindById(gomock.Any(), int64(1)).SetArg(0, u).Return(nil)
+		cmd := ports.CommandUserRecoveryCodesRegenerate{UserId: 1, Password: "qwerty123", Code: code}
+		assert.Equal(t, domain.ErrKey, d.RegenerateRecoveryCodes(&cmd))
+	})
+
+	t.Run("no password", func(t *testing.T) {
+		d := Driver{Conf: testConf()}
+		cmd := ports.CommandUserRecoveryCodesRegenerate{UserId: 1, Code: code}
+		assert.Equal(t, domain.ErrValue, d.RegenerateRecoveryCodes(&cmd))
+	})
+}
@@ -0,0 +1,105 @@
+package user
+
+import (
+	"log"
+	"net"
+	"time"
+
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+// loginThrottleKeys returns the account and the address keys of
+// the login. The address key is empty if the address is unknown.
+func loginThrottleKeys(email string, ipAddr net.IP) (account, addr string) {
+	account = "email:" + email
+	if ip := domain.ClientAddr(ipAddr); ip != "" {
+		addr = "ip:" + ip
+	}
+	return account, addr
+}
+
+// checkLoginThrottle fails with domain.ErrThrottled if either key
+// of the login is locked, setting retryAfter to the time left.
+func (d *Driver) checkLoginThrottle(retryAfter *time.Duration, account, addr string, now time.Time) error {
+	keys := []string{account}
+	if addr != "" {
+		keys = append(keys, addr)
+	}
+
+	lockedUntil, err := d.Throttles.FindLockedUntil(keys...)
+	if err != nil {
+		return err
+	}
+	if now.Before(lockedUntil) {
+		*retryAfter = lockedUntil.Sub(now)
+		return domain.ErrThrottled
+	}
+	return nil
+}
+
+// loginFailed counts the failure for both keys of the login and
+// locks those past their free failures. Wrong passwords and wrong
+// second factor codes count alike.
+func (d *Driver) loginFailed(account, addr string, now time.Time) error {
+	if err := d.recordLoginFailure(account, now, d.Conf.LoginFreeFailures, d.Conf.LoginLockoutFailures); err != nil {
+		return err
+	}
+	if addr != "" {
+		if err := d.recordLoginFailure(addr, now, d.Conf.LoginIpFreeFailures, d.Conf.LoginIpLockoutFailures); err != nil {
+			return err
+		}
+	}
+	return domain.ErrCredentials
+}
+
+func (d *Driver) recordLoginFailure(key string, now time.Time, free, lockout int) error {
+	var t domain.LoginThrottle
+	windowStart := now.Add(-time.Duration(d.Conf.LoginFailureWindow))
+	if err := d.Throttles.RecordFailure(&t, key, now, windowStart); err != nil {
+		return err
+	}
+
+	delay := d.loginDelay(t.Failures, free, lockout)
+	if delay == 0 {
+		return nil
+	}
+	return d.Throttles.Lock(key, now.Add(delay))
+}
+
+// loginDelay doubles the lock with every failure past the free
+// ones. The full lockout applies once the lockout count is reached.
+func (d *Driver) loginDelay(failures, free, lockout int) time.Duration {
+	ttl := time.Duration(d.Conf.LoginLockoutTtl)
+	switch {
+	case failures >= lockout:
+		return ttl
+	case failures <= free:
+		return 0
+	}
+
+	delay := time.Duration(d.Conf.LoginDelayBase)
+	for i := free + 1; i < failures && delay < ttl; i++ {
+		delay *= 2
+	}
+	if delay > ttl {
+		return ttl
+	}
+	return delay
+}
+
+// PurgeLoginThrottles deletes the counters past the failure window,
+// their next failure would start over anyway.
+func (d *Driver) PurgeLoginThrottles() (int, error) {
+	now := time.Now()
+	return d.Throttles.Purge(now.Add(-time.Duration(d.Conf.LoginFailureWindow)), now)
+}
+
+// resetLoginThrottle forgets the failures of the account once the
+// login completes, including the second factor. The address keeps
+// its counter, valid credentials don't vouch for the client.
+// Failures are logged only, the login goes on.
+func (d *Driver) resetLoginThrottle(account string) {
+	if err := d.Throttles.Reset(account); err != nil {
+		log.Println("DriverUser: can't reset login throttle:", err)
+	}
+}
@@ -0,0 +1,112 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: login_throttle_repository.go
+
+// Package user is a generated GoMock package.
+package user
+
+import (
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/boris-army/server/internal/core/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRepositoryLoginThrottle is a mock of RepositoryLoginThrottle interface.
+type MockRepositoryLoginThrottle struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryLoginThrottleMockRecorder
+}
+
+// MockRepositoryLoginThrottleMockRecorder is the mock recorder for MockRepositoryLoginThrottle.
+type MockRepositoryLoginThrottleMockRecorder struct {
+	mock *MockRepositoryLoginThrottle
+}
+
+// NewMockRepositoryLoginThrottle creates a new mock instance.
+func NewMockRepositoryLoginThrottle(ctrl *gomock.Controller) *MockRepositoryLoginThrottle {
+	mock := &MockRepositoryLoginThrottle{ctrl: ctrl}
+	mock.recorder = &MockRepositoryLoginThrottleMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepositoryLoginThrottle) EXPECT() *MockRepositoryLoginThrottleMockRecorder {
+	return m.recorder
+}
+
+// FindLockedUntil mocks base method.
+func (m *MockRepositoryLoginThrottle) FindLockedUntil(keys ...string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range keys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindLockedUntil", varargs...)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindLockedUntil indicates an expected call of FindLockedUntil.
+func (mr *MockRepositoryLoginThrottleMockRecorder) FindLockedUntil(keys ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindLockedUntil", reflect.TypeOf((*MockRepositoryLoginThrottle)(nil).FindLockedUntil), keys...)
+}
+
+// Lock mocks base method.
+func (m *MockRepositoryLoginThrottle) Lock(key string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", key, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockRepositoryLoginThrottleMockRecorder) Lock(key, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockRepositoryLoginThrottle)(nil).Lock), key, until)
+}
+
+// Purge mocks base method.
+func (m *MockRepositoryLoginThrottle) Purge(failedBefore, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", failedBefore, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockRepositoryLoginThrottleMockRecorder) Purge(failedBefore, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockRepositoryLoginThrottle)(nil).Purge), failedBefore, now)
+}
+
+// RecordFailure mocks base method.
+func (m *MockRepositoryLoginThrottle) RecordFailure(dst *domain.LoginThrottle, key string, at, windowStart time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailure", dst, key, at, windowStart)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockRepositoryLoginThrottleMockRecorder) RecordFailure(dst, key, at, windowStart interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockRepositoryLoginThrottle)(nil).RecordFailure), dst, key, at, windowStart)
+}
+
+// Reset mocks base method.
+func (m *MockRepositoryLoginThrottle) Reset(key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockRepositoryLoginThrottleMockRecorder) Reset(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockRepositoryLoginThrottle)(nil).Reset), key)
+}
@@ -0,0 +1,115 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/boris-army/server/internal/core/domain"
+)
+
+type PgxLoginThrottleRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func (p *PgxLoginThrottleRepository) FindLockedUntil(keys ...string) (time.Time, error) {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer conn.Release()
+
+	const selectLock = `
+		select max(locked_until)
+		from login_throttles
+		where key = any($1)
+	`
+	var lockedUntil pgtype.Timestamptz
+	if err := conn.QueryRow(context.Background(), selectLock, keys).Scan(&lockedUntil); err != nil {
+		return time.Time{}, err
+	}
+	if lockedUntil.Status != pgtype.Present {
+		return time.Time{}, nil
+	}
+
+	return lockedUntil.Time, nil
+}
+
+func (p *PgxLoginThrottleRepository) RecordFailure(dst *domain.LoginThrottle, key string, at, windowStart time.Time) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const upsertThrottle = `
+		insert into login_throttles as t (key, failures, last_failed_at)
+		values ($1, 1, $2)
+		on conflict (key) do update set
+			failures = case
+				when t.last_failed_at < $3 then 1
+				else t.failures + 1
+			end,
+			last_failed_at = excluded.last_failed_at
+		returning key, failures, last_failed_at, locked_until
+	`
+	var lockedUntil pgtype.Timestamptz
+	row := conn.QueryRow(context.Background(), upsertThrottle, key, at, windowStart)
+	if err := row.Scan(&dst.Key, &dst.Failures, &dst.LastFailedAt, &lockedUntil); err != nil {
+		return err
+	}
+	dst.LockedUntil = lockedUntil.Time
+
+	return nil
+}
+
+func (p *PgxLoginThrottleRepository) Lock(key string, until time.Time) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const updateThrottle = `
+		update login_throttles set locked_until = $2
+		where key = $1
+	`
+	_, err = conn.Exec(context.Background(), updateThrottle, key, until)
+	return err
+}
+
+func (p *PgxLoginThrottleRepository) Reset(key string) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const deleteThrottle = `
+		delete from login_throttles
+		where key = $1
+	`
+	_, err = conn.Exec(context.Background(), deleteThrottle, key)
+	return err
+}
+
+func (p *PgxLoginThrottleRepository) Purge(failedBefore, now time.Time) (int, error) {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	const deleteThrottles = `
+		delete from login_throttles
+		where last_failed_at < $1 and (locked_until is null or locked_until <= $2)
+	`
+	tag, err := conn.Exec(context.Background(), deleteThrottles, failedBefore, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
@@ -6,6 +6,7 @@ package user
 
 import (
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/boris-army/server/internal/core/domain"
 	gomock "github.com/golang/mock/gomock"
@@ -34,6 +35,63 @@ func (m *MockRepositoryUser) EXPECT() *MockRepositoryUserMockRecorder {
 	return m.recorder
 }
 
+// ConfirmEmail mocks base method.
+func (m *MockRepositoryUser) ConfirmEmail(tokenDigest []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmail", tokenDigest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmEmail indicates an expected call of ConfirmEmail.
+func (mr *MockRepositoryUserMockRecorder) ConfirmEmail(tokenDigest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmail", reflect.TypeOf((*MockRepositoryUser)(nil).ConfirmEmail), tokenDigest)
+}
+
+// ConsumeLoginChallengeAttempt mocks base method.
+func (m *MockRepositoryUser) ConsumeLoginChallengeAttempt(dst *domain.UserLoginChallenge, tokenDigest []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeLoginChallengeAttempt", dst, tokenDigest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeLoginChallengeAttempt indicates an expected call of ConsumeLoginChallengeAttempt.
+func (mr *MockRepositoryUserMockRecorder) ConsumeLoginChallengeAttempt(dst, tokenDigest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeLoginChallengeAttempt", reflect.TypeOf((*MockRepositoryUser)(nil).ConsumeLoginChallengeAttempt), dst, tokenDigest)
+}
+
+// ConsumePhoneVerificationAttempt mocks base method.
+func (m *MockRepositoryUser) ConsumePhoneVerificationAttempt(dst *domain.UserPhoneVerification, userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumePhoneVerificationAttempt", dst, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumePhoneVerificationAttempt indicates an expected call of ConsumePhoneVerificationAttempt.
+func (mr *MockRepositoryUserMockRecorder) ConsumePhoneVerificationAttempt(dst, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumePhoneVerificationAttempt", reflect.TypeOf((*MockRepositoryUser)(nil).ConsumePhoneVerificationAttempt), dst, userId)
+}
+
+// CountRecoveryCodes mocks base method.
+func (m *MockRepositoryUser) CountRecoveryCodes(userId int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRecoveryCodes", userId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRecoveryCodes indicates an expected call of CountRecoveryCodes.
+func (mr *MockRepositoryUserMockRecorder) CountRecoveryCodes(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRecoveryCodes", reflect.TypeOf((*MockRepositoryUser)(nil).CountRecoveryCodes), userId)
+}
+
 // Create mocks base method.
 func (m *MockRepositoryUser) Create(arg0 *domain.User) error {
 	m.ctrl.T.Helper()
@@ -47,3 +105,313 @@ func (mr *MockRepositoryUserMockRecorder) Create(arg0 interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepositoryUser)(nil).Create), arg0)
 }
+
+// DeleteLoginChallenge mocks base method.
+func (m *MockRepositoryUser) DeleteLoginChallenge(tokenDigest []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginChallenge", tokenDigest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLoginChallenge indicates an expected call of DeleteLoginChallenge.
+func (mr *MockRepositoryUserMockRecorder) DeleteLoginChallenge(tokenDigest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginChallenge", reflect.TypeOf((*MockRepositoryUser)(nil).DeleteLoginChallenge), tokenDigest)
+}
+
+// DisableTotp mocks base method.
+func (m *MockRepositoryUser) DisableTotp(userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTotp", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTotp indicates an expected call of DisableTotp.
+func (mr *MockRepositoryUserMockRecorder) DisableTotp(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTotp", reflect.TypeOf((*MockRepositoryUser)(nil).DisableTotp), userId)
+}
+
+// EnableTotp mocks base method.
+func (m *MockRepositoryUser) EnableTotp(userId, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTotp", userId, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTotp indicates an expected call of EnableTotp.
+func (mr *MockRepositoryUserMockRecorder) EnableTotp(userId, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTotp", reflect.TypeOf((*MockRepositoryUser)(nil).EnableTotp), userId, step)
+}
+
+// FindByEmail mocks base method.
+func (m *MockRepositoryUser) FindByEmail(dst *domain.User, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByEmail", dst, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindByEmail indicates an expected call of FindByEmail.
+func (mr *MockRepositoryUserMockRecorder) FindByEmail(dst, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByEmail", reflect.TypeOf((*MockRepositoryUser)(nil).FindByEmail), dst, email)
+}
+
+// FindById mocks base method.
+func (m *MockRepositoryUser) FindById(dst *domain.User, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindById", dst, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindById indicates an expected call of FindById.
+func (mr *MockRepositoryUserMockRecorder) FindById(dst, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindById", reflect.TypeOf((*MockRepositoryUser)(nil).FindById), dst, id)
+}
+
+// FindEmailConfirmation mocks base method.
+func (m *MockRepositoryUser) FindEmailConfirmation(dst *domain.UserEmailConfirmation, userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindEmailConfirmation", dst, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindEmailConfirmation indicates an expected call of FindEmailConfirmation.
+func (mr *MockRepositoryUserMockRecorder) FindEmailConfirmation(dst, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEmailConfirmation", reflect.TypeOf((*MockRepositoryUser)(nil).FindEmailConfirmation), dst, userId)
+}
+
+// FindPasswordReset mocks base method.
+func (m *MockRepositoryUser) FindPasswordReset(dst *domain.UserPasswordReset, userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPasswordReset", dst, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindPasswordReset indicates an expected call of FindPasswordReset.
+func (mr *MockRepositoryUserMockRecorder) FindPasswordReset(dst, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPasswordReset", reflect.TypeOf((*MockRepositoryUser)(nil).FindPasswordReset), dst, userId)
+}
+
+// FindPasswordResetByToken mocks base method.
+func (m *MockRepositoryUser) FindPasswordResetByToken(dst *domain.UserPasswordReset, tokenDigest []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPasswordResetByToken", dst, tokenDigest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindPasswordResetByToken indicates an expected call of FindPasswordResetByToken.
+func (mr *MockRepositoryUserMockRecorder) FindPasswordResetByToken(dst, tokenDigest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPasswordResetByToken", reflect.TypeOf((*MockRepositoryUser)(nil).FindPasswordResetByToken), dst, tokenDigest)
+}
+
+// FindPhoneVerification mocks base method.
+func (m *MockRepositoryUser) FindPhoneVerification(dst *domain.UserPhoneVerification, userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPhoneVerification", dst, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindPhoneVerification indicates an expected call of FindPhoneVerification.
+func (mr *MockRepositoryUserMockRecorder) FindPhoneVerification(dst, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPhoneVerification", reflect.TypeOf((*MockRepositoryUser)(nil).FindPhoneVerification), dst, userId)
+}
+
+// FindRecoveryCode mocks base method.
+func (m *MockRepositoryUser) FindRecoveryCode(dst *domain.UserRecoveryCode, userId int64, codeDigest []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRecoveryCode", dst, userId, codeDigest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindRecoveryCode indicates an expected call of FindRecoveryCode.
+func (mr *MockRepositoryUserMockRecorder) FindRecoveryCode(dst, userId, codeDigest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRecoveryCode", reflect.TypeOf((*MockRepositoryUser)(nil).FindRecoveryCode), dst, userId, codeDigest)
+}
+
+// PurgeUnconfirmed mocks base method.
+func (m *MockRepositoryUser) PurgeUnconfirmed(createdBefore time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUnconfirmed", createdBefore)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeUnconfirmed indicates an expected call of PurgeUnconfirmed.
+func (mr *MockRepositoryUserMockRecorder) PurgeUnconfirmed(createdBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUnconfirmed", reflect.TypeOf((*MockRepositoryUser)(nil).PurgeUnconfirmed), createdBefore)
+}
+
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockRepositoryUser) ReplaceRecoveryCodes(userId int64, codes []domain.UserRecoveryCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", userId, codes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockRepositoryUserMockRecorder) ReplaceRecoveryCodes(userId, codes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockRepositoryUser)(nil).ReplaceRecoveryCodes), userId, codes)
+}
+
+// ResetPassword mocks base method.
+func (m *MockRepositoryUser) ResetPassword(tokenDigest, passwordDigest []byte) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", tokenDigest, passwordDigest)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockRepositoryUserMockRecorder) ResetPassword(tokenDigest, passwordDigest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockRepositoryUser)(nil).ResetPassword), tokenDigest, passwordDigest)
+}
+
+// SaveEmailConfirmation mocks base method.
+func (m *MockRepositoryUser) SaveEmailConfirmation(arg0 *domain.UserEmailConfirmation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEmailConfirmation", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEmailConfirmation indicates an expected call of SaveEmailConfirmation.
+func (mr *MockRepositoryUserMockRecorder) SaveEmailConfirmation(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEmailConfirmation", reflect.TypeOf((*MockRepositoryUser)(nil).SaveEmailConfirmation), arg0)
+}
+
+// SaveLoginChallenge mocks base method.
+func (m *MockRepositoryUser) SaveLoginChallenge(arg0 *domain.UserLoginChallenge) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveLoginChallenge", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveLoginChallenge indicates an expected call of SaveLoginChallenge.
+func (mr *MockRepositoryUserMockRecorder) SaveLoginChallenge(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLoginChallenge", reflect.TypeOf((*MockRepositoryUser)(nil).SaveLoginChallenge), arg0)
+}
+
+// SavePasswordReset mocks base method.
+func (m *MockRepositoryUser) SavePasswordReset(arg0 *domain.UserPasswordReset) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePasswordReset", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePasswordReset indicates an expected call of SavePasswordReset.
+func (mr *MockRepositoryUserMockRecorder) SavePasswordReset(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePasswordReset", reflect.TypeOf((*MockRepositoryUser)(nil).SavePasswordReset), arg0)
+}
+
+// SavePhoneVerification mocks base method.
+func (m *MockRepositoryUser) SavePhoneVerification(arg0 *domain.UserPhoneVerification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePhoneVerification", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePhoneVerification indicates an expected call of SavePhoneVerification.
+func (mr *MockRepositoryUserMockRecorder) SavePhoneVerification(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePhoneVerification", reflect.TypeOf((*MockRepositoryUser)(nil).SavePhoneVerification), arg0)
+}
+
+// SaveTotpSecret mocks base method.
+func (m *MockRepositoryUser) SaveTotpSecret(userId int64, secret []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTotpSecret", userId, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveTotpSecret indicates an expected call of SaveTotpSecret.
+func (mr *MockRepositoryUserMockRecorder) SaveTotpSecret(userId, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTotpSecret", reflect.TypeOf((*MockRepositoryUser)(nil).SaveTotpSecret), userId, secret)
+}
+
+// UpdatePasswordDigest mocks base method.
+func (m *MockRepositoryUser) UpdatePasswordDigest(userId int64, passwordDigest []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePasswordDigest", userId, passwordDigest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePasswordDigest indicates an expected call of UpdatePasswordDigest.
+func (mr *MockRepositoryUserMockRecorder) UpdatePasswordDigest(userId, passwordDigest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePasswordDigest", reflect.TypeOf((*MockRepositoryUser)(nil).UpdatePasswordDigest), userId, passwordDigest)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockRepositoryUser) UseRecoveryCode(id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockRepositoryUserMockRecorder) UseRecoveryCode(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockRepositoryUser)(nil).UseRecoveryCode), id)
+}
+
+// UseTotpStep mocks base method.
+func (m *MockRepositoryUser) UseTotpStep(userId, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTotpStep", userId, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseTotpStep indicates an expected call of UseTotpStep.
+func (mr *MockRepositoryUserMockRecorder) UseTotpStep(userId, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTotpStep", reflect.TypeOf((*MockRepositoryUser)(nil).UseTotpStep), userId, step)
+}
+
+// VerifyPhone mocks base method.
+func (m *MockRepositoryUser) VerifyPhone(userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyPhone", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyPhone indicates an expected call of VerifyPhone.
+func (mr *MockRepositoryUserMockRecorder) VerifyPhone(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyPhone", reflect.TypeOf((*MockRepositoryUser)(nil).VerifyPhone), userId)
+}
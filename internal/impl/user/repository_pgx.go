@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 
 	"github.com/boris-army/server/internal/core/domain"
@@ -41,7 +42,7 @@ func (p *PgxRepository) Create(user *domain.User) error {
 			has_proof,
 			password_digest,
 			created_at
-		) values (default, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) values (default, $1, $2, $3, $4, $5, $6, $7, $8)
 		returning id
   `
 	row := conn.QueryRow(
@@ -70,3 +71,716 @@ func (p *PgxRepository) Create(user *domain.User) error {
 
 	return nil
 }
+
+func (p *PgxRepository) FindByEmail(dst *domain.User, email string) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const selectUser = `
+		select
+			id,
+			email,
+			surname,
+			given_names,
+			phone164,
+			born_at,
+			has_proof,
+			roles,
+			password_digest,
+			created_at,
+			totp_secret,
+			totp_enabled,
+			totp_last_step
+		from users
+		where email = $1
+	`
+	row := conn.QueryRow(context.Background(), selectUser, email)
+	if err := scanUser(row, dst); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrKey
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p *PgxRepository) FindById(dst *domain.User, id int64) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const selectUser = `
+		select
+			id,
+			email,
+			surname,
+			given_names,
+			phone164,
+			born_at,
+			has_proof,
+			roles,
+			password_digest,
+			created_at,
+			totp_secret,
+			totp_enabled,
+			totp_last_step
+		from users
+		where id = $1
+	`
+	row := conn.QueryRow(context.Background(), selectUser, id)
+	if err := scanUser(row, dst); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrKey
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p *PgxRepository) UpdatePasswordDigest(userId int64, passwordDigest []byte) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const updateUser = `
+		update users set password_digest = $2
+		where id = $1
+	`
+	tag, err := conn.Exec(context.Background(), updateUser, userId, passwordDigest)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrKey
+	}
+
+	return nil
+}
+
+func scanUser(row pgx.Row, dst *domain.User) error {
+	return row.Scan(
+		&dst.Id,
+		&dst.Email,
+		&dst.Surname,
+		&dst.GivenNames,
+		&dst.Phone164,
+		&dst.BornAt,
+		&dst.HasProof,
+		&dst.Roles,
+		&dst.PasswordDigest,
+		&dst.CreatedAt,
+		&dst.TotpSecret,
+		&dst.TotpEnabled,
+		&dst.TotpLastStep,
+	)
+}
+
+func (p *PgxRepository) SaveEmailConfirmation(c *domain.UserEmailConfirmation) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const upsertConfirmation = `
+		insert into user_email_confirmations (
+			user_id,
+			token_digest,
+			sent_at,
+			expires_at
+		) values ($1, $2, $3, $4)
+		on conflict (user_id) do update set
+			token_digest = excluded.token_digest,
+			sent_at = excluded.sent_at,
+			expires_at = excluded.expires_at
+	`
+	_, err = conn.Exec(
+		context.Background(), upsertConfirmation,
+		c.UserId,
+		c.TokenDigest,
+		c.SentAt,
+		c.ExpiresAt,
+	)
+	return err
+}
+
+func (p *PgxRepository) FindEmailConfirmation(dst *domain.UserEmailConfirmation, userId int64) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const selectConfirmation = `
+		select user_id, token_digest, sent_at, expires_at
+		from user_email_confirmations
+		where user_id = $1
+	`
+	row := conn.QueryRow(context.Background(), selectConfirmation, userId)
+	if err := row.Scan(&dst.UserId, &dst.TokenDigest, &dst.SentAt, &dst.ExpiresAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrKey
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p *PgxRepository) ConfirmEmail(tokenDigest []byte) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	return conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		const deleteConfirmation = `
+			delete from user_email_confirmations
+			where token_digest = $1
+			returning user_id, expires_at
+		`
+		var (
+			userId    int64
+			expiresAt time.Time
+		)
+		row := tx.QueryRow(context.Background(), deleteConfirmation, tokenDigest)
+		if err := row.Scan(&userId, &expiresAt); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrKey
+			}
+			return err
+		}
+		if expiresAt.Before(time.Now()) {
+			// Keep the confirmation, the purge will take care of it.
+			return domain.ErrExpired
+		}
+
+		const updateUser = `
+			update users set has_proof = has_proof | $2
+			where id = $1
+		`
+		_, err := tx.Exec(context.Background(), updateUser, userId, domain.UserProofEmail)
+		return err
+	})
+}
+
+func (p *PgxRepository) PurgeUnconfirmed(createdBefore time.Time) (int, error) {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	const deleteUsers = `
+		delete from users
+		where has_proof & $1 = 0 and created_at < $2
+	`
+	tag, err := conn.Exec(context.Background(), deleteUsers, domain.UserProofEmail, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+func (p *PgxRepository) SavePhoneVerification(v *domain.UserPhoneVerification) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const upsertVerification = `
+		insert into user_phone_verifications (
+			user_id,
+			phone164,
+			code_digest,
+			attempts,
+			sent_at,
+			expires_at
+		) values ($1, $2, $3, $4, $5, $6)
+		on conflict (user_id) do update set
+			phone164 = excluded.phone164,
+			code_digest = excluded.code_digest,
+			attempts = excluded.attempts,
+			sent_at = excluded.sent_at,
+			expires_at = excluded.expires_at
+	`
+	_, err = conn.Exec(
+		context.Background(), upsertVerification,
+		v.UserId,
+		int64(v.Phone164),
+		v.CodeDigest,
+		v.Attempts,
+		v.SentAt,
+		v.ExpiresAt,
+	)
+	return err
+}
+
+func (p *PgxRepository) FindPhoneVerification(dst *domain.UserPhoneVerification, userId int64) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const selectVerification = `
+		select user_id, phone164, code_digest, attempts, sent_at, expires_at
+		from user_phone_verifications
+		where user_id = $1
+	`
+	row := conn.QueryRow(context.Background(), selectVerification, userId)
+	return scanPhoneVerification(row, dst)
+}
+
+func (p *PgxRepository) ConsumePhoneVerificationAttempt(dst *domain.UserPhoneVerification, userId int64) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const updateVerification = `
+		update user_phone_verifications set attempts = attempts + 1
+		where user_id = $1
+		returning user_id, phone164, code_digest, attempts, sent_at, expires_at
+	`
+	row := conn.QueryRow(context.Background(), updateVerification, userId)
+	return scanPhoneVerification(row, dst)
+}
+
+func (p *PgxRepository) VerifyPhone(userId int64) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	return conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		const deleteVerification = `
+			delete from user_phone_verifications
+			where user_id = $1
+			returning phone164
+		`
+		var phone164 int64
+		if err := tx.QueryRow(context.Background(), deleteVerification, userId).Scan(&phone164); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrKey
+			}
+			return err
+		}
+
+		const updateUser = `
+			update users set phone164 = $2, has_proof = has_proof | $3
+			where id = $1
+		`
+		_, err := tx.Exec(context.Background(), updateUser, userId, phone164, domain.UserProofPhone)
+		return err
+	})
+}
+
+func scanPhoneVerification(row pgx.Row, dst *domain.UserPhoneVerification) error {
+	var phone164 int64
+	err := row.Scan(
+		&dst.UserId,
+		&phone164,
+		&dst.CodeDigest,
+		&dst.Attempts,
+		&dst.SentAt,
+		&dst.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrKey
+		}
+		return err
+	}
+	dst.Phone164 = uint64(phone164)
+	return nil
+}
+
+func (p *PgxRepository) SavePasswordReset(r *domain.UserPasswordReset) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const upsertReset = `
+		insert into user_password_resets (
+			user_id,
+			token_digest,
+			sent_at,
+			expires_at
+		) values ($1, $2, $3, $4)
+		on conflict (user_id) do update set
+			token_digest = excluded.token_digest,
+			sent_at = excluded.sent_at,
+			expires_at = excluded.expires_at
+	`
+	_, err = conn.Exec(
+		context.Background(), upsertReset,
+		r.UserId,
+		r.TokenDigest,
+		r.SentAt,
+		r.ExpiresAt,
+	)
+	return err
+}
+
+func (p *PgxRepository) FindPasswordReset(dst *domain.UserPasswordReset, userId int64) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const selectReset = `
+		select user_id, token_digest, sent_at, expires_at
+		from user_password_resets
+		where user_id = $1
+	`
+	row := conn.QueryRow(context.Background(), selectReset, userId)
+	if err := row.Scan(&dst.UserId, &dst.TokenDigest, &dst.SentAt, &dst.ExpiresAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrKey
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p *PgxRepository) FindPasswordResetByToken(dst *domain.UserPasswordReset, tokenDigest []byte) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const selectReset = `
+		select user_id, token_digest, sent_at, expires_at
+		from user_password_resets
+		where token_digest = $1
+	`
+	row := conn.QueryRow(context.Background(), selectReset, tokenDigest)
+	if err := row.Scan(&dst.UserId, &dst.TokenDigest, &dst.SentAt, &dst.ExpiresAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrKey
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p *PgxRepository) ResetPassword(tokenDigest, passwordDigest []byte) (int64, error) {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	var userId int64
+	err = conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		const deleteReset = `
+			delete from user_password_resets
+			where token_digest = $1
+			returning user_id, expires_at
+		`
+		var expiresAt time.Time
+		row := tx.QueryRow(context.Background(), deleteReset, tokenDigest)
+		if err := row.Scan(&userId, &expiresAt); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrKey
+			}
+			return err
+		}
+		if expiresAt.Before(time.Now()) {
+			// Keep the reset, a new request replaces it.
+			return domain.ErrExpired
+		}
+
+		const updateUser = `
+			update users set password_digest = $2
+			where id = $1
+		`
+		_, err := tx.Exec(context.Background(), updateUser, userId, passwordDigest)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return userId, nil
+}
+
+func (p *PgxRepository) SaveTotpSecret(userId int64, secret []byte) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const updateUser = `
+		update users set totp_secret = $2
+		where id = $1 and not totp_enabled
+	`
+	tag, err := conn.Exec(context.Background(), updateUser, userId, secret)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrKey
+	}
+
+	return nil
+}
+
+func (p *PgxRepository) EnableTotp(userId, step int64) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const updateUser = `
+		update users set totp_enabled = true, totp_last_step = $2
+		where id = $1 and totp_secret is not null and not totp_enabled
+	`
+	tag, err := conn.Exec(context.Background(), updateUser, userId, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrKey
+	}
+
+	return nil
+}
+
+func (p *PgxRepository) DisableTotp(userId int64) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	return conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		const deleteCodes = `
+			delete from user_recovery_codes
+			where user_id = $1
+		`
+		if _, err := tx.Exec(context.Background(), deleteCodes, userId); err != nil {
+			return err
+		}
+
+		const updateUser = `
+			update users set totp_secret = null, totp_enabled = false, totp_last_step = 0
+			where id = $1
+		`
+		_, err := tx.Exec(context.Background(), updateUser, userId)
+		return err
+	})
+}
+
+func (p *PgxRepository) UseTotpStep(userId, step int64) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	// The condition makes concurrent uses of the same code race
+	// for a single row update.
+	const updateUser = `
+		update users set totp_last_step = $2
+		where id = $1 and totp_last_step < $2
+	`
+	tag, err := conn.Exec(context.Background(), updateUser, userId, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrExpired
+	}
+
+	return nil
+}
+
+func (p *PgxRepository) SaveLoginChallenge(c *domain.UserLoginChallenge) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	return conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		const deleteExpired = `
+			delete from user_login_challenges
+			where user_id = $1 and expires_at < $2
+		`
+		if _, err := tx.Exec(context.Background(), deleteExpired, c.UserId, time.Now()); err != nil {
+			return err
+		}
+
+		const insertChallenge = `
+			insert into user_login_challenges (
+				token_digest,
+				user_id,
+				attempts,
+				expires_at
+			) values ($1, $2, $3, $4)
+		`
+		_, err := tx.Exec(
+			context.Background(), insertChallenge,
+			c.TokenDigest,
+			c.UserId,
+			c.Attempts,
+			c.ExpiresAt,
+		)
+		return err
+	})
+}
+
+func (p *PgxRepository) ConsumeLoginChallengeAttempt(dst *domain.UserLoginChallenge, tokenDigest []byte) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const updateChallenge = `
+		update user_login_challenges set attempts = attempts + 1
+		where token_digest = $1
+		returning token_digest, user_id, attempts, expires_at
+	`
+	row := conn.QueryRow(context.Background(), updateChallenge, tokenDigest)
+	if err := row.Scan(&dst.TokenDigest, &dst.UserId, &dst.Attempts, &dst.ExpiresAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrKey
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p *PgxRepository) DeleteLoginChallenge(tokenDigest []byte) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const deleteChallenge = `
+		delete from user_login_challenges
+		where token_digest = $1
+	`
+	_, err = conn.Exec(context.Background(), deleteChallenge, tokenDigest)
+	return err
+}
+
+func (p *PgxRepository) ReplaceRecoveryCodes(userId int64, codes []domain.UserRecoveryCode) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	return conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		const deleteCodes = `
+			delete from user_recovery_codes
+			where user_id = $1
+		`
+		if _, err := tx.Exec(context.Background(), deleteCodes, userId); err != nil {
+			return err
+		}
+
+		const insertCode = `
+			insert into user_recovery_codes (user_id, code_digest)
+			values ($1, $2)
+		`
+		batch := &pgx.Batch{}
+		for i := range codes {
+			batch.Queue(insertCode, userId, codes[i].CodeDigest)
+		}
+		return tx.SendBatch(context.Background(), batch).Close()
+	})
+}
+
+func (p *PgxRepository) FindRecoveryCode(dst *domain.UserRecoveryCode, userId int64, codeDigest []byte) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const selectCode = `
+		select id, user_id, code_digest
+		from user_recovery_codes
+		where user_id = $1 and code_digest = $2 and used_at is null
+	`
+	row := conn.QueryRow(context.Background(), selectCode, userId, codeDigest)
+	if err := row.Scan(&dst.Id, &dst.UserId, &dst.CodeDigest); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrKey
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p *PgxRepository) UseRecoveryCode(id int64) error {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	const updateCode = `
+		update user_recovery_codes set used_at = $2
+		where id = $1 and used_at is null
+	`
+	tag, err := conn.Exec(context.Background(), updateCode, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrExpired
+	}
+
+	return nil
+}
+
+func (p *PgxRepository) CountRecoveryCodes(userId int64) (int, error) {
+	conn, err := p.Pool.Acquire(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	const countCodes = `
+		select count(*)
+		from user_recovery_codes
+		where user_id = $1 and used_at is null
+	`
+	var n int
+	if err := conn.QueryRow(context.Background(), countCodes, userId).Scan(&n); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
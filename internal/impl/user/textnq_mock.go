@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: bytesnq_driver.go
+
+// Package user is a generated GoMock package.
+package user
+
+import (
+	reflect "reflect"
+
+	ports "github.com/boris-army/server/internal/core/ports"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDriverTextNQ is a mock of DriverTextNQ interface.
+type MockDriverTextNQ struct {
+	ctrl     *gomock.Controller
+	recorder *MockDriverTextNQMockRecorder
+}
+
+// MockDriverTextNQMockRecorder is the mock recorder for MockDriverTextNQ.
+type MockDriverTextNQMockRecorder struct {
+	mock *MockDriverTextNQ
+}
+
+// NewMockDriverTextNQ creates a new mock instance.
+func NewMockDriverTextNQ(ctrl *gomock.Controller) *MockDriverTextNQ {
+	mock := &MockDriverTextNQ{ctrl: ctrl}
+	mock.recorder = &MockDriverTextNQMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDriverTextNQ) EXPECT() *MockDriverTextNQMockRecorder {
+	return m.recorder
+}
+
+// Submit mocks base method.
+func (m *MockDriverTextNQ) Submit(recipient string, renderFn ports.DriverTextNSRenderFn, deliverFn ports.DriverTextNSDeliverFn) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Submit", recipient, renderFn, deliverFn)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Submit indicates an expected call of Submit.
+func (mr *MockDriverTextNQMockRecorder) Submit(recipient, renderFn, deliverFn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockDriverTextNQ)(nil).Submit), recipient, renderFn, deliverFn)
+}
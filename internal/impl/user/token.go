@@ -0,0 +1,81 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"strconv"
+
+	"github.com/kzmnbrs/sly"
+
+	"github.com/boris-army/server/internal/core/ports"
+)
+
+// newSecretToken generates a single-use token. The token is sent
+// to the user while its digest is stored.
+func newSecretToken() (token string, digest []byte, err error) {
+	var raw [32]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return "", nil, err
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw[:])
+	return token, secretTokenDigest(token), nil
+}
+
+func secretTokenDigest(token string) []byte {
+	digest := sha256.Sum256(sly.S2B(token))
+	return digest[:]
+}
+
+var phoneCodeMax = big.NewInt(1_000_000)
+
+// newPhoneCode generates a numeric one-time code of ports.PhoneCodeLen
+// digits. The code is sent to the user while its digest is stored.
+func newPhoneCode(userId int64) (code string, digest []byte, err error) {
+	n, err := rand.Int(rand.Reader, phoneCodeMax)
+	if err != nil {
+		return "", nil, err
+	}
+	var buf [ports.PhoneCodeLen]byte
+	v := n.Int64()
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = byte('0' + v%10)
+		v /= 10
+	}
+	code = string(buf[:])
+	return code, phoneCodeDigest(userId, code), nil
+}
+
+// phoneCodeDigest salts the code with the user id, so equal codes
+// of different users have different digests.
+func phoneCodeDigest(userId int64, code string) []byte {
+	h := sha256.New()
+	h.Write(strconv.AppendInt(nil, userId, 10))
+	h.Write([]byte{':'})
+	h.Write(sly.S2B(code))
+	return h.Sum(nil)
+}
+
+const recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+// newRecoveryCode generates a code of ports.RecoveryCodeLen, see
+// ports.IsRecoveryCode. The 75 random bits make a fast digest
+// of the code, see ports.SecretDigester, as good as a slow one.
+func newRecoveryCode() (string, error) {
+	var raw [ports.RecoveryCodeLen - 2]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return "", err
+	}
+
+	var buf [ports.RecoveryCodeLen]byte
+	for i, j := 0, 0; i < len(buf); i++ {
+		if i == 5 || i == 11 {
+			buf[i] = '-'
+			continue
+		}
+		buf[i] = recoveryCodeAlphabet[raw[j]%byte(len(recoveryCodeAlphabet))]
+		j++
+	}
+	return string(buf[:]), nil
+}
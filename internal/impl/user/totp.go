@@ -0,0 +1,140 @@
+package user
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kzmnbrs/sly"
+
+	"github.com/boris-army/server/internal/core/ports"
+)
+
+// RFC 6238 with the defaults every authenticator app supports:
+// HMAC-SHA1, 30 second steps, 6 digits.
+const (
+	totpSecretLen = 20
+	totpPeriod    = 30
+)
+
+var totpSecretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func newTotpSecret() ([]byte, error) {
+	secret := make([]byte, totpSecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func totpStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// totpCode computes the code of the step as in RFC 4226.
+func totpCode(secret []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	n := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	n %= 1000000
+
+	code := strconv.AppendUint(make([]byte, 0, ports.TotpCodeLen), uint64(n), 10)
+	for len(code) < ports.TotpCodeLen {
+		code = append([]byte{'0'}, code...)
+	}
+	return string(code)
+}
+
+// matchTotp looks for the step of the code within skew steps
+// around now.
+func matchTotp(secret []byte, code string, now time.Time, skew int) (int64, bool) {
+	cur := totpStep(now)
+	for step := cur - int64(skew); step <= cur+int64(skew); step++ {
+		if subtle.ConstantTimeCompare(sly.S2B(totpCode(secret, step)), sly.S2B(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func totpUri(issuer, account string, secret []byte) string {
+	q := url.Values{}
+	q.Set("secret", totpSecretEncoding.EncodeToString(secret))
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", strconv.Itoa(ports.TotpCodeLen))
+	q.Set("period", strconv.Itoa(totpPeriod))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
+
+var errTotpSecret = errors.New("totp: can't open secret")
+
+// sealTotpSecret encrypts the secret with AES-GCM. The user id is
+// authenticated along, so a secret can't be moved to another user.
+func sealTotpSecret(key string, userId int64, secret []byte) ([]byte, error) {
+	aead, err := newTotpAead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(secret)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, secret, totpAdditionalData(userId)), nil
+}
+
+func openTotpSecret(key string, userId int64, sealed []byte) ([]byte, error) {
+	aead, err := newTotpAead(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errTotpSecret
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	secret, err := aead.Open(nil, nonce, ciphertext, totpAdditionalData(userId))
+	if err != nil {
+		return nil, errTotpSecret
+	}
+	return secret, nil
+}
+
+func newTotpAead(key string) (cipher.AEAD, error) {
+	k := sha256.Sum256(sly.S2B(key))
+	block, err := aes.NewCipher(k[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func totpAdditionalData(userId int64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(userId))
+	return b[:]
+}
@@ -0,0 +1,57 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTotpCode_Rfc6238(t *testing.T) {
+	// RFC 6238 appendix B, SHA1, truncated to 6 digits.
+	secret := []byte("12345678901234567890")
+	assert.Equal(t, "287082", totpCode(secret, totpStep(time.Unix(59, 0))))
+	assert.Equal(t, "081804", totpCode(secret, totpStep(time.Unix(1111111109, 0))))
+	assert.Equal(t, "005924", totpCode(secret, totpStep(time.Unix(1234567890, 0))))
+}
+
+func TestMatchTotp(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	now := time.Unix(1111111109, 0)
+
+	step, ok := matchTotp(secret, "081804", now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, totpStep(now), step)
+
+	prev := totpCode(secret, totpStep(now)-1)
+	_, ok = matchTotp(secret, prev, now, 0)
+	assert.False(t, ok)
+	step, ok = matchTotp(secret, prev, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, totpStep(now)-1, step)
+}
+
+func TestTotpUri(t *testing.T) {
+	uri := totpUri("Boris", "pgarin@old.me", []byte("12345678901234567890"))
+	assert.Equal(t, "otpauth://totp/Boris:pgarin@old.me?algorithm=SHA1&digits=6&issuer=Boris&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", uri)
+}
+
+func TestSealTotpSecret(t *testing.T) {
+	const key = "0123456789abcdef0123456789abcdef"
+	secret := []byte("12345678901234567890")
+
+	sealed, err := sealTotpSecret(key, 1, secret)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(sealed), string(secret))
+
+	opened, err := openTotpSecret(key, 1, sealed)
+	assert.Nil(t, err)
+	assert.Equal(t, secret, opened)
+
+	_, err = openTotpSecret(key, 2, sealed)
+	assert.NotNil(t, err)
+	_, err = openTotpSecret("fedcba9876543210fedcba9876543210", 1, sealed)
+	assert.NotNil(t, err)
+	_, err = openTotpSecret(key, 1, sealed[:4])
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,25 @@
+create table users (
+	id              bigserial primary key,
+	email           text        not null unique,
+	surname         text        not null,
+	given_names     text        not null,
+	phone164        bigint      not null default 0,
+	born_at         timestamptz not null,
+	has_proof       integer     not null default 0,
+	password_digest bytea       not null,
+	created_at      timestamptz not null
+);
+
+create table sessions (
+	id            bigserial primary key,
+	user_id       bigint      not null references users (id) on delete cascade,
+	type          smallint    not null,
+	ip_addr       text        not null,
+	user_agent    text        not null,
+	created_at    timestamptz not null,
+	expires_at    timestamptz not null,
+	terminated_at timestamptz
+);
+
+create index sessions_terminated_at_idx on sessions (terminated_at)
+	where terminated_at is not null;
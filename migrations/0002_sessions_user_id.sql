@@ -0,0 +1,2 @@
+create index sessions_user_id_idx on sessions (user_id, id desc)
+	where terminated_at is null;
@@ -0,0 +1,9 @@
+create table user_email_confirmations (
+	user_id      bigint      primary key references users (id) on delete cascade,
+	token_digest bytea       not null unique,
+	sent_at      timestamptz not null,
+	expires_at   timestamptz not null
+);
+
+create index users_unconfirmed_created_at_idx on users (created_at)
+	where has_proof & 2 = 0;
@@ -0,0 +1,8 @@
+create table user_phone_verifications (
+	user_id     bigint      primary key references users (id) on delete cascade,
+	phone164    bigint      not null,
+	code_digest bytea       not null,
+	attempts    integer     not null default 0,
+	sent_at     timestamptz not null,
+	expires_at  timestamptz not null
+);
@@ -0,0 +1,17 @@
+create table user_attestations (
+	id           bigserial primary key,
+	user_id      bigint      not null references users (id) on delete cascade,
+	status       smallint    not null,
+	note         text        not null default '',
+	submitted_at timestamptz not null,
+	reviewer_id  bigint      references users (id) on delete set null,
+	reason       text        not null default '',
+	decided_at   timestamptz
+);
+
+-- status 1 is pending.
+create unique index user_attestations_pending_user_id_idx on user_attestations (user_id)
+	where status = 1;
+
+create index user_attestations_pending_id_idx on user_attestations (id)
+	where status = 1;
@@ -0,0 +1,6 @@
+create table user_password_resets (
+	user_id      bigint      primary key references users (id) on delete cascade,
+	token_digest bytea       not null unique,
+	sent_at      timestamptz not null,
+	expires_at   timestamptz not null
+);
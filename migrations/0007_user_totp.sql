@@ -0,0 +1,13 @@
+alter table users
+	add column totp_secret    bytea,
+	add column totp_enabled   boolean not null default false,
+	add column totp_last_step bigint  not null default 0;
+
+create table user_login_challenges (
+	token_digest bytea       primary key,
+	user_id      bigint      not null references users (id) on delete cascade,
+	attempts     integer     not null default 0,
+	expires_at   timestamptz not null
+);
+
+create index user_login_challenges_user_id_idx on user_login_challenges (user_id);
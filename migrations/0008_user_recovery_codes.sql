@@ -0,0 +1,7 @@
+create table user_recovery_codes (
+	id          bigserial   primary key,
+	user_id     bigint      not null references users (id) on delete cascade,
+	code_digest bytea       not null,
+	used_at     timestamptz,
+	unique (user_id, code_digest)
+);
@@ -0,0 +1,8 @@
+create table login_throttles (
+	key            text        primary key,
+	failures       integer     not null,
+	last_failed_at timestamptz not null,
+	locked_until   timestamptz
+);
+
+create index login_throttles_last_failed_at_idx on login_throttles (last_failed_at);
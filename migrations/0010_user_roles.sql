@@ -0,0 +1,2 @@
+alter table users
+	add column roles integer not null default 0;